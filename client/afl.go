@@ -0,0 +1,298 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	aflInputDir  = "afl-in"
+	aflOutputDir = "afl-out"
+	// afl-fuzz names the output of a single instance "default"
+	aflQueueDir   = aflOutputDir + "/default/queue"
+	aflCrashesDir = aflOutputDir + "/default/crashes"
+
+	aflRegressionTimeout = 30 * time.Second
+)
+
+// afl-fuzz exits with 0 when the -V session ends. crashes are collected from the crashes directory
+func aflExitCodeToStatus(exitCode int) string {
+	if exitCode == aflSuccessExitCode {
+		return "pass"
+	}
+	return "failed"
+}
+
+// aflCrashExitCode returns the exit code of the run which saved a crash file, 128+signal like a shell does.
+// afl-fuzz names the crash files after the signal e.g id:000000,sig:11,src:000000,time:1234,op:havoc,rep:2
+func aflCrashExitCode(crashFile string) int {
+	for _, field := range strings.Split(crashFile, ",") {
+		if strings.HasPrefix(field, "sig:") {
+			if signal, err := strconv.Atoi(strings.TrimPrefix(field, "sig:")); err == nil {
+				return 128 + signal
+			}
+		}
+	}
+	return 1
+}
+
+// aflTargetArgs returns the target command line. job args are passed to the target and "@@" is
+// replaced by afl with the input file. Without "@@" the input is passed on stdin
+func (c *FuzzitClient) aflTargetArgs() []string {
+	return append([]string{"./fuzzer"}, splitAndRemoveEmpty(c.currentJob.Args, " ")...)
+}
+
+// prepareAFLInput copies the corpus, seed and additional corpus into a flat directory for afl-fuzz
+func (c *FuzzitClient) prepareAFLInput() error {
+	count, err := c.copyInputFiles(aflInputDir)
+	if err != nil {
+		return err
+	}
+
+	if count == 0 {
+		// afl-fuzz refuses to start without at least one input
+		log.Println("no files in corpus and seed. starting from an empty input")
+		if err := ioutil.WriteFile(filepath.Join(aflInputDir, "empty"), []byte("\n"), 0644); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// uploadAFLCrashes uploads the crashes found by afl-fuzz that weren't uploaded yet
+func (c *FuzzitClient) uploadAFLCrashes(uploaded map[string]bool) error {
+	files, err := ioutil.ReadDir(aflCrashesDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	for _, file := range files {
+		if file.IsDir() || file.Name() == "README.txt" || uploaded[file.Name()] {
+			continue
+		}
+		log.Printf("afl-fuzz found crash %s", file.Name())
+		crashFile := filepath.Join(aflCrashesDir, file.Name())
+		// afl-fuzz doesn't show the output of the target so the crash is run again to capture it
+		setLastLines(nil)
+		if _, err := c.runInput(c.aflTargetArgs(), "@@", crashFile, aflRegressionTimeout); err != nil && err != context.DeadlineExceeded {
+			return err
+		}
+		if err := c.refreshToken(); err != nil {
+			return err
+		}
+		if err := c.uploadCrashFile(crashFile, aflCrashExitCode(file.Name()), getLastLines()); err != nil {
+			return err
+		}
+		uploaded[file.Name()] = true
+	}
+
+	return nil
+}
+
+func (c *FuzzitClient) runAFLFuzzing() error {
+	uploaded := map[string]bool{}
+	for {
+		if err := c.prepareAFLInput(); err != nil {
+			return err
+		}
+		if err := os.RemoveAll(aflOutputDir); err != nil {
+			return err
+		}
+
+		args := append([]string{
+			"-i", aflInputDir,
+			"-o", aflOutputDir,
+			"-V", fmt.Sprintf("%d", fuzzingInterval),
+			"--",
+		}, c.aflTargetArgs()...)
+
+		log.Println("Running fuzzing with: afl-fuzz " + strings.Join(args, " "))
+		cmd := exec.Command("afl-fuzz", args...)
+		cmd.Env = append(os.Environ(),
+			"AFL_NO_UI=1",
+			"AFL_SKIP_CPUFREQ=1",
+			"AFL_I_DONT_CARE_ABOUT_MISSING_CRASHES=1")
+		if err := appendPrefixToCmd(cmd); err != nil {
+			return err
+		}
+
+		exitCode, cancelled, err := c.runFuzzerSession(cmd, func() error {
+			return c.uploadAFLCrashes(uploaded)
+		})
+		if err != nil {
+			return err
+		}
+		if cancelled {
+			return nil
+		}
+
+		if err := c.uploadAFLCrashes(uploaded); err != nil {
+			return err
+		}
+		if exitCode != aflSuccessExitCode {
+			return c.transitionStatus(c.exitStatus(exitCode))
+		}
+
+		if err := (aflEngine{}).Merge(c); err != nil {
+			return err
+		}
+		log.Print("process finished successfully")
+
+		if len(uploaded) > 0 {
+			return c.transitionStatus("crash")
+		}
+	}
+}
+
+func (c *FuzzitClient) runAFLRegression() error {
+	regressionFiles, err := c.inputFiles()
+	if err != nil {
+		return err
+	}
+	if len(regressionFiles) == 0 {
+		log.Println("no files in corpus and seed. skipping run")
+		c.transitionStatus("pass")
+		return nil
+	}
+
+	log.Println("Running regression...")
+	for _, regressionFile := range regressionFiles {
+		// only the output of the failing input is uploaded
+		setLastLines(nil)
+		status := "crash"
+		exitCode, err := c.runInput(c.aflTargetArgs(), "@@", regressionFile, aflRegressionTimeout)
+		if err == context.DeadlineExceeded {
+			status = "timeout"
+		} else if err != nil {
+			return err
+		} else if exitCode == 0 {
+			continue
+		}
+
+		log.Printf("%s failed with exit code %d", regressionFile, exitCode)
+		if !c.updateDB {
+			// if this is local regression we want to exit with error code so the ci can fail
+			return fmt.Errorf("regression failed on %s with exit code %d", regressionFile, exitCode)
+		}
+		if err := c.uploadCrashFile(regressionFile, exitCode, getLastLines()); err != nil {
+			return err
+		}
+		return c.transitionStatus(status)
+	}
+
+	return c.transitionStatus("pass")
+}
+
+type aflEngine struct{}
+
+func init() {
+	RegisterEngine("afl", aflEngine{})
+}
+
+func (aflEngine) Prepare(c *FuzzitClient) error {
+	if _, err := os.Stat("fuzzer"); os.IsNotExist(err) {
+		c.transitionStatus("failed")
+		return fmt.Errorf("fuzzer executable doesnt exist")
+	}
+	if c.currentJob.Type != "regression" {
+		if _, err := exec.LookPath("afl-fuzz"); err != nil {
+			c.transitionStatus("failed")
+			return fmt.Errorf("afl-fuzz is not installed in the host image")
+		}
+	}
+	return os.Chmod("./fuzzer", 0770)
+}
+
+func (aflEngine) Fuzz(c *FuzzitClient) error {
+	return c.runAFLFuzzing()
+}
+
+func (aflEngine) Regress(c *FuzzitClient) error {
+	return c.runAFLRegression()
+}
+
+// Merge syncs the afl-fuzz queue back into the corpus, minimized with afl-cmin when it is available
+func (aflEngine) Merge(c *FuzzitClient) error {
+	queue, err := ioutil.ReadDir(aflQueueDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			log.Println("nothing to merge. skipping...")
+			return nil
+		}
+		return err
+	}
+
+	if err := os.RemoveAll("merge"); err != nil {
+		return err
+	}
+
+	if _, err := exec.LookPath("afl-cmin"); err == nil {
+		args := append([]string{"-i", aflQueueDir, "-o", "merge", "--"}, c.aflTargetArgs()...)
+		log.Println("Running merge with: afl-cmin " + strings.Join(args, " "))
+		cmd := exec.Command("afl-cmin", args...)
+		cmd.Env = append(os.Environ(), "AFL_SKIP_CPUFREQ=1")
+		if err := appendPrefixToCmd(cmd); err != nil {
+			return err
+		}
+		if err := cmd.Run(); err != nil {
+			return err
+		}
+	} else {
+		log.Println("afl-cmin not found. syncing the queue without minimizing")
+		if err := os.Mkdir("merge", 0644); err != nil {
+			return err
+		}
+		for _, file := range queue {
+			if file.IsDir() {
+				continue
+			}
+			if _, err := copyFile(filepath.Join("merge", file.Name()), filepath.Join(aflQueueDir, file.Name())); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := c.refreshToken(); err != nil {
+		return err
+	}
+
+	if err := os.RemoveAll("corpus"); err != nil {
+		return err
+	}
+
+	if err := os.Rename("merge", "corpus"); err != nil {
+		return err
+	}
+
+	if err := c.archiveAndUpload("corpus",
+		fmt.Sprintf("orgs/%s/targets/%s/corpus.tar.gz", c.Org, c.currentJob.TargetId),
+		"corpus.tar.gz"); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (aflEngine) ClassifyExit(exitCode int) string {
+	return aflExitCodeToStatus(exitCode)
+}
+
+func (aflEngine) DefaultHost() string {
+	return "aflplusplus/aflplusplus:v4.08c"
+}
+
+func (aflEngine) RawFuzzer() string {
+	return ""
+}
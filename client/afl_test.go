@@ -0,0 +1,155 @@
+package client
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// aflFuzzer crashes on inputs containing "crash" and prints a line for the other inputs. it sleeps after
+// the report as the output is read asynchronously
+const aflFuzzer = `#!/bin/sh
+if grep -q crash "$1"; then
+  echo "==1==ERROR: AddressSanitizer: SEGV on unknown address 0x000000000000" >&2
+  sleep 0.2
+  exit 1
+fi
+echo "ran $1" >&2
+sleep 0.2
+`
+
+// newAFLJob returns a file client running an afl job in a temporary working directory with the fuzzer
+// and corpus. The returned function restores the working directory and removes the directories
+func newAFLJob(t *testing.T, corpus map[string]string) (*FuzzitClient, Backend, func()) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fuzzer is a shell script")
+	}
+	c, backend, _, cleanup := newFileClient(t)
+	if err := c.CreateTarget(Target{Name: "parse-complex"}, "", false); err != nil {
+		cleanup()
+		t.Fatal(err)
+	}
+	c.updateDB = true
+	c.jobId = "afl-job"
+	c.currentJob = Job{TargetId: "parse-complex", Engine: "afl", Args: "@@", Status: "in progress"}
+	if err := backend.SetJob("fuzzitdev", c.jobId, c.currentJob); err != nil {
+		cleanup()
+		t.Fatal(err)
+	}
+
+	workDir, err := ioutil.TempDir("", "fuzzit-afl")
+	if err != nil {
+		cleanup()
+		t.Fatal(err)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		cleanup()
+		t.Fatal(err)
+	}
+	restore := func() {
+		os.Chdir(wd)
+		os.RemoveAll(workDir)
+		cleanup()
+	}
+	if err := os.Chdir(workDir); err != nil {
+		restore()
+		t.Fatal(err)
+	}
+
+	files := map[string]string{"fuzzer": aflFuzzer}
+	for name, content := range corpus {
+		files[name] = content
+	}
+	for _, dir := range []string{"corpus", "seed", "additional-corpus"} {
+		if err := os.Mkdir(dir, 0755); err != nil {
+			restore()
+			t.Fatal(err)
+		}
+	}
+	for name, content := range files {
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			restore()
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(name, []byte(content), 0755); err != nil {
+			restore()
+			t.Fatal(err)
+		}
+	}
+	return c, backend, restore
+}
+
+func aflJobCrashes(t *testing.T, backend Backend) []map[string]interface{} {
+	crashes, err := backend.ListDocuments("orgs/fuzzitdev/targets/parse-complex/jobs/afl-job/crashes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return crashes
+}
+
+func TestAFLCrashExitCode(t *testing.T) {
+	for crashFile, exitCode := range map[string]int{
+		"id:000000,sig:11,src:000000,time:1234,op:havoc,rep:2": 139,
+		"id:000001,sig:06,src:000003,time:5678,op:flip1,pos:4": 134,
+		"crash": 1,
+	} {
+		if received := aflCrashExitCode(crashFile); received != exitCode {
+			t.Errorf("%s: expected %d received %d", crashFile, exitCode, received)
+		}
+	}
+}
+
+func TestAFLRegression(t *testing.T) {
+	c, backend, cleanup := newAFLJob(t, map[string]string{"corpus/a": "pass", "corpus/b": "crash", "seed/c": "pass"})
+	defer cleanup()
+
+	if err := c.runAFLRegression(); err != nil {
+		t.Fatal(err)
+	}
+	job, err := backend.GetJob("fuzzitdev", "parse-complex", "afl-job")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Status != "crash" {
+		t.Errorf("was expecting status crash received %s", job.Status)
+	}
+
+	// only the output of the crashing input is uploaded
+	crashes := aflJobCrashes(t, backend)
+	if len(crashes) != 1 {
+		t.Fatalf("was expecting a crash received %v", crashes)
+	}
+	lastLines, _ := crashes[0]["last_lines"].(string)
+	if crashes[0]["exit_code"] != float64(1) || !strings.Contains(lastLines, "AddressSanitizer") || strings.Contains(lastLines, "ran ") {
+		t.Errorf("unexpected crash %v", crashes[0])
+	}
+}
+
+func TestUploadAFLCrashes(t *testing.T) {
+	c, backend, cleanup := newAFLJob(t, map[string]string{
+		filepath.Join(aflCrashesDir, "id:000000,sig:06,src:000000,time:1234,op:havoc,rep:2"): "crash",
+		filepath.Join(aflCrashesDir, "README.txt"):                                           "afl-fuzz crashes",
+	})
+	defer cleanup()
+
+	uploaded := map[string]bool{}
+	for i := 0; i < 2; i++ {
+		if err := c.uploadAFLCrashes(uploaded); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the crash is uploaded once with the exit code of its signal and the output of running it again
+	crashes := aflJobCrashes(t, backend)
+	if len(crashes) != 1 || len(uploaded) != 1 {
+		t.Fatalf("was expecting a crash received %v", crashes)
+	}
+	lastLines, _ := crashes[0]["last_lines"].(string)
+	if crashes[0]["exit_code"] != float64(134) || crashes[0]["bug_type"] != "SEGV" || !strings.Contains(lastLines, "AddressSanitizer") {
+		t.Errorf("unexpected crash %v", crashes[0])
+	}
+}
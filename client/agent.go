@@ -2,15 +2,18 @@ package client
 
 import (
 	"bufio"
-	"cloud.google.com/go/firestore"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"os"
 	"os/exec"
 	"os/signal"
+	"path/filepath"
+	"sync"
 	"syscall"
+	"time"
 )
 
 const (
@@ -23,15 +26,58 @@ const (
 	jqfCrashExitCode   = 3
 	jqfSuccessExitCode = 0
 
+	aflSuccessExitCode = 0
+
+	honggfuzzSuccessExitCode = 0
+
+	goTestFailExitCode    = 1
+	goTestPanicExitCode   = 2
+	goTestSuccessExitCode = 0
+
 	fuzzingInterval = 3600
 
 	AgentGeneralError      = 1
 	AgentNoPermissionError = 22
 )
 
-var lastLines []string
+// lastLines keeps the last lines of output of the fuzzer. It's appended to by the goroutines reading the
+// output so it's only accessed through getLastLines and setLastLines
+var (
+	lastLinesMu sync.Mutex
+	lastLines   []string
+)
+
+// getLastLines returns a copy of the last lines of output of the fuzzer
+func getLastLines() []string {
+	lastLinesMu.Lock()
+	defer lastLinesMu.Unlock()
+	return append([]string{}, lastLines...)
+}
+
+func setLastLines(lines []string) {
+	lastLinesMu.Lock()
+	defer lastLinesMu.Unlock()
+	lastLines = lines
+}
+
+func addLastLine(line string) {
+	lastLinesMu.Lock()
+	defer lastLinesMu.Unlock()
+	lastLines = append(lastLines, line)
+	if len(lastLines) > 1000 {
+		lastLines = lastLines[500:]
+	}
+}
+
+// errJobCancelled is returned when the job was cancelled before the agent started it
+var errJobCancelled = errors.New("job was cancelled")
 
 func appendPrefixToCmd(cmd *exec.Cmd) error {
+	return appendPrefixToCmdWithCallback(cmd, nil)
+}
+
+// appendPrefixToCmdWithCallback is appendPrefixToCmd calling onLine, if set, with every line of output
+func appendPrefixToCmdWithCallback(cmd *exec.Cmd, onLine func(line string)) error {
 	stderr, err := cmd.StderrPipe()
 	if err != nil {
 		return err
@@ -46,9 +92,10 @@ func appendPrefixToCmd(cmd *exec.Cmd) error {
 		for scanner.Scan() {
 			msg := scanner.Text()
 			fmt.Printf("FUZZER: %s\n", msg)
-			lastLines = append(lastLines, msg)
-			if len(lastLines) > 1000 {
-				lastLines = lastLines[500:]
+			addLastLine(msg)
+			jobLogs.add(msg)
+			if onLine != nil {
+				onLine(msg)
 			}
 		}
 	}()
@@ -57,21 +104,24 @@ func appendPrefixToCmd(cmd *exec.Cmd) error {
 }
 
 func (c *FuzzitClient) transitionToInProgress() error {
-	ctx := context.Background()
-	job := Job{}
 	if c.updateDB {
 		// transaction doesnt work for now at go client with oauth token
-		jobRef := c.firestoreClient.Doc(fmt.Sprintf("orgs/%s/targets/%s/jobs/%s", c.Org, c.currentJob.TargetId, c.jobId))
-		docsnap, err := jobRef.Get(ctx)
+		job, err := c.backend.GetJob(c.Org, c.currentJob.TargetId, c.jobId)
 		if err != nil {
 			return err
 		}
-		err = docsnap.DataTo(&job)
-		if err != nil {
-			return err
+		// the agent is only given the engine, type and args of the job
+		if c.currentJob.CPUs == "" {
+			c.currentJob.CPUs = job.CPUs
+		}
+		if c.currentJob.MinimizeRuns == 0 {
+			c.currentJob.MinimizeRuns = job.MinimizeRuns
+		}
+		if job.Status == "cancelled" {
+			return errJobCancelled
 		}
 		if job.Status == "queued" {
-			_, err := jobRef.Update(ctx, []firestore.Update{{Path: "status", Value: "in progress"}})
+			err := c.backend.UpdateJobStatus(c.Org, c.currentJob.TargetId, c.jobId, "in progress")
 			if err != nil {
 				return err
 			}
@@ -80,16 +130,21 @@ func (c *FuzzitClient) transitionToInProgress() error {
 	return nil
 }
 
-func (c *FuzzitClient) transitionStatus(status string) error {
-	ctx := context.Background()
+// acknowledgeCancel tells the user the fuzzer of a cancelled job was stopped. Jobs stopped for any
+// other reason keep their status
+func (c *FuzzitClient) acknowledgeCancel(status string) error {
+	if status != "cancelled" {
+		return nil
+	}
+	return c.transitionStatus("stopped")
+}
 
+func (c *FuzzitClient) transitionStatus(status string) error {
 	if !c.updateDB {
 		return nil
 	}
 
-	// transaction doesnt work for now at go client with oauth token
-	jobRef := c.firestoreClient.Doc(fmt.Sprintf("orgs/%s/targets/%s/jobs/%s", c.Org, c.currentJob.TargetId, c.jobId))
-	_, err := jobRef.Update(ctx, []firestore.Update{{Path: "status", Value: status}})
+	err := c.backend.UpdateJobStatus(c.Org, c.currentJob.TargetId, c.jobId, status)
 	if err != nil {
 		return err
 	}
@@ -102,7 +157,7 @@ func (c *FuzzitClient) RunFuzzer(job Job, jobId string, updateDB bool) error {
 		return err
 	}
 
-	channel := make(chan os.Signal)
+	channel := make(chan os.Signal, 1)
 	signal.Notify(channel, syscall.SIGTERM)
 	go func() {
 		<-channel
@@ -115,6 +170,21 @@ func (c *FuzzitClient) RunFuzzer(job Job, jobId string, updateDB bool) error {
 	c.updateDB = updateDB
 
 	if err := c.transitionToInProgress(); err != nil {
+		if err == errJobCancelled {
+			log.Println("job was cancelled before it started. exiting...")
+			return c.acknowledgeCancel("cancelled")
+		}
+		return err
+	}
+
+	if c.updateDB {
+		stopShippingLogs := c.startShippingLogs()
+		defer stopShippingLogs()
+	}
+
+	engine, err := GetEngine(c.currentJob.Engine)
+	if err != nil {
+		c.transitionStatus("failed")
 		return err
 	}
 
@@ -166,14 +236,202 @@ func (c *FuzzitClient) RunFuzzer(job Job, jobId string, updateDB bool) error {
 		return err
 	}
 
-	var err error
-	if c.currentJob.Engine == "jqf" {
-		err = c.RunJQF()
-	} else if c.currentJob.Engine == "go-fuzz" {
-		err = c.runGoFuzz()
+	if err := engine.Prepare(c); err != nil {
+		return err
+	}
+
+	if c.currentJob.Type == "regression" {
+		err = engine.Regress(c)
 	} else {
-		err = c.runLibFuzzer()
+		err = engine.Fuzz(c)
 	}
 
 	return err
 }
+
+// RunReproduce replays a crash of the job with the fuzzer of the job. The crash is the only input of
+// a local regression so the engine prints its report and fails
+func (c *FuzzitClient) RunReproduce(job Job, jobId string, crashId string) error {
+	if err := c.refreshToken(); err != nil {
+		return err
+	}
+
+	c.currentJob = job
+	c.currentJob.Type = "regression"
+	c.jobId = jobId
+	c.updateDB = false
+	c.crashId = crashId
+
+	engine, err := GetEngine(c.currentJob.Engine)
+	if err != nil {
+		return err
+	}
+
+	for _, dir := range []string{"seed", "corpus", "additional-corpus"} {
+		if err := createDirIfNotExist(dir); err != nil {
+			return err
+		}
+	}
+
+	log.Println("downloading fuzzer")
+	if err := c.DownloadAndExtractFuzzer(".", c.currentJob.TargetId, jobId); err != nil {
+		return err
+	}
+
+	log.Println("downloading crash")
+	artifact, _, err := c.crashArtifact(c.currentJob.TargetId, jobId, crashId)
+	if err != nil {
+		return err
+	}
+	if _, err := c.backend.DownloadFile(filepath.Join("corpus", "crash-"+crashId), artifact); err != nil {
+		return err
+	}
+
+	if err := engine.Prepare(c); err != nil {
+		return err
+	}
+
+	return engine.Regress(c)
+}
+
+// exitCodeFromError returns the exit code of a finished process. Processes killed by a signal
+// return 128+signal like a shell does
+func exitCodeFromError(err error) (int, error) {
+	if err == nil {
+		return 0, nil
+	}
+	if exiterr, ok := err.(*exec.ExitError); ok {
+		if status, ok := exiterr.Sys().(syscall.WaitStatus); ok {
+			if status.Signaled() {
+				return 128 + int(status.Signal()), nil
+			}
+			return status.ExitStatus(), nil
+		}
+	}
+	return 0, err
+}
+
+// runFuzzerSession starts cmd and waits for it to exit. Every minute it calls onTick, if set, and kills
+// the fuzzer if the job is no longer in progress, in which case cancelled is true
+func (c *FuzzitClient) runFuzzerSession(cmd *exec.Cmd, onTick func() error) (exitCode int, cancelled bool, err error) {
+	if err := cmd.Start(); err != nil {
+		return 0, false, err
+	}
+	done := make(chan error)
+	go func() { done <- cmd.Wait() }()
+	timeout := time.After(60 * time.Second)
+	for {
+		select {
+		case <-timeout:
+			if onTick != nil {
+				if err := onTick(); err != nil {
+					cmd.Process.Kill()
+					return 0, false, err
+				}
+			}
+			if c.updateDB {
+				if err := c.refreshToken(); err != nil {
+					cmd.Process.Kill()
+					return 0, false, err
+				}
+				fuzzingJob, err := c.backend.GetJob(c.Org, c.currentJob.TargetId, c.jobId)
+				if err != nil {
+					cmd.Process.Kill()
+					return 0, false, err
+				}
+				if fuzzingJob.Status != "in progress" {
+					log.Println("job was cancel by user. exiting...")
+					cmd.Process.Kill()
+					<-done
+					return 0, true, c.acknowledgeCancel(fuzzingJob.Status)
+				}
+			}
+			timeout = time.After(60 * time.Second)
+		case err := <-done:
+			if err != nil {
+				log.Printf("process finished with error = %v\n", err)
+			}
+			exitCode, err := exitCodeFromError(err)
+			if err != nil {
+				return 0, false, err
+			}
+			log.Printf("Exit Status: %d", exitCode)
+			return exitCode, false, nil
+		}
+	}
+}
+
+// inputFiles lists the corpus, seed and additional corpus files
+func (c *FuzzitClient) inputFiles() ([]string, error) {
+	var inputFiles []string
+	for _, dir := range []string{"corpus", "seed", "additional-corpus"} {
+		files, err := listFiles(dir)
+		if err != nil {
+			return nil, err
+		}
+		inputFiles = append(inputFiles, files...)
+	}
+	return inputFiles, nil
+}
+
+// copyInputFiles recreates dst with a flat copy of the corpus, seed and additional corpus for engines
+// taking a single input directory. It returns the number of files copied
+func (c *FuzzitClient) copyInputFiles(dst string) (int, error) {
+	if err := os.RemoveAll(dst); err != nil {
+		return 0, err
+	}
+	if err := os.Mkdir(dst, 0644); err != nil {
+		return 0, err
+	}
+
+	inputFiles, err := c.inputFiles()
+	if err != nil {
+		return 0, err
+	}
+	for i, inputFile := range inputFiles {
+		if _, err := copyFile(filepath.Join(dst, fmt.Sprintf("%d-%s", i, filepath.Base(inputFile))), inputFile); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(inputFiles), nil
+}
+
+// runInput runs a single input through a fuzzer that isn't driven by its engine. placeholder in args is
+// replaced by the input file; without it the input is passed on stdin. A run killed after timeout
+// returns context.DeadlineExceeded
+func (c *FuzzitClient) runInput(args []string, placeholder string, inputFile string, timeout time.Duration) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	useStdin := true
+	args = append([]string{}, args...)
+	for i, arg := range args {
+		if arg == placeholder {
+			args[i] = inputFile
+			useStdin = false
+		}
+	}
+
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	if useStdin {
+		stdin, err := os.Open(inputFile)
+		if err != nil {
+			return 0, err
+		}
+		defer stdin.Close()
+		cmd.Stdin = stdin
+	}
+	if err := appendPrefixToCmd(cmd); err != nil {
+		return 0, err
+	}
+
+	exitCode, err := exitCodeFromError(cmd.Run())
+	if err != nil {
+		return 0, err
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		return exitCode, context.DeadlineExceeded
+	}
+	return exitCode, nil
+}
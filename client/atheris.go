@@ -0,0 +1,106 @@
+package client
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// the fuzzer archive holds the fuzz script as fuzzer and optionally a wheelhouse directory with the wheels
+// of its requirements and a requirements.txt
+const atherisWheelhouse = "wheelhouse"
+
+var atherisCommand = []string{"python3", "fuzzer"}
+
+func runPip(args ...string) error {
+	args = append([]string{"-m", "pip", "install", "--disable-pip-version-check"}, args...)
+	log.Println("Running: python3 " + strings.Join(args, " "))
+	cmd := exec.Command("python3", args...)
+	if err := appendPrefixToCmd(cmd); err != nil {
+		return err
+	}
+	return cmd.Run()
+}
+
+// installWheelhouse installs the requirements of the fuzz script from the wheelhouse, without an index
+func installWheelhouse() error {
+	if _, err := os.Stat(atherisWheelhouse); os.IsNotExist(err) {
+		return nil
+	}
+
+	if _, err := os.Stat("requirements.txt"); err == nil {
+		return runPip("--no-index", "--find-links="+atherisWheelhouse, "-r", "requirements.txt")
+	}
+
+	wheels, err := filepath.Glob(filepath.Join(atherisWheelhouse, "*.whl"))
+	if err != nil {
+		return err
+	}
+	if len(wheels) == 0 {
+		return nil
+	}
+	log.Printf("installing %d wheels", len(wheels))
+	return runPip(append([]string{"--no-index", "--find-links=" + atherisWheelhouse}, wheels...)...)
+}
+
+type atherisEngine struct{}
+
+func init() {
+	RegisterEngine("python-atheris", atherisEngine{})
+}
+
+func (atherisEngine) Prepare(c *FuzzitClient) error {
+	if _, err := exec.LookPath("python3"); err != nil {
+		c.transitionStatus("failed")
+		return fmt.Errorf("python3 must be installed in the docker to run atheris fuzzer")
+	}
+	if _, err := os.Stat("fuzzer"); os.IsNotExist(err) {
+		c.transitionStatus("failed")
+		return fmt.Errorf("fuzz script doesnt exist")
+	}
+
+	if err := installWheelhouse(); err != nil {
+		c.transitionStatus("failed")
+		return err
+	}
+
+	// the python3 host images come with a pinned atheris
+	if err := exec.Command("python3", "-c", "import atheris").Run(); err != nil {
+		c.transitionStatus("failed")
+		return fmt.Errorf("atheris must be installed in the docker to run atheris fuzzer. use the stretch-python3 or bionic-python3 host")
+	}
+
+	return nil
+}
+
+// atheris passes the command line to libFuzzer so the libFuzzer flow is reused with the fuzz script
+func (atherisEngine) Fuzz(c *FuzzitClient) error {
+	return c.runLibFuzzerFuzzing(atherisCommand)
+}
+
+func (atherisEngine) Regress(c *FuzzitClient) error {
+	return c.runLibFuzzerRegression(atherisCommand)
+}
+
+func (atherisEngine) Merge(c *FuzzitClient) error {
+	return c.runlibFuzzerMerge(atherisCommand)
+}
+
+func (atherisEngine) MinimizeCrash(c *FuzzitClient, path string, dst string, runs int) error {
+	return c.minimizeLibFuzzerCrash(atherisCommand, path, dst, runs)
+}
+
+func (atherisEngine) ClassifyExit(exitCode int) string {
+	return libFuzzerExitCodeToStatus(exitCode)
+}
+
+func (atherisEngine) DefaultHost() string {
+	return HostToDocker["stretch-python3"]
+}
+
+func (atherisEngine) RawFuzzer() string {
+	return ""
+}
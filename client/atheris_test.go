@@ -0,0 +1,81 @@
+package client
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestAtherisEngine(t *testing.T) {
+	engine, err := GetEngine("python-atheris")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if host := engine.DefaultHost(); !strings.HasPrefix(host, "gcr.io/fuzzit-public/stretch-python3:") {
+		t.Errorf("was expecting the pinned stretch-python3 image received %s", host)
+	}
+	if _, ok := HostToDocker["bionic-python3"]; !ok {
+		t.Error("was expecting a bionic-python3 host")
+	}
+	for exitCode, status := range map[int]string{0: "pass", 1: "crash", 77: "timeout"} {
+		if received := engine.ClassifyExit(exitCode); received != status {
+			t.Errorf("exit code %d: expected %s received %s", exitCode, status, received)
+		}
+	}
+}
+
+func TestAtherisPrepare(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake python3 is a shell script")
+	}
+	dir, err := ioutil.TempDir("", "fuzzit-atheris")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// python3 logs its args and has no atheris module
+	binDir := filepath.Join(dir, "bin")
+	if err := os.Mkdir(binDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	python := "#!/bin/sh\necho \"$@\" >> " + filepath.Join(dir, "python3.log") + "\n[ \"$1\" != \"-c\" ]\n"
+	if err := ioutil.WriteFile(filepath.Join(binDir, "python3"), []byte(python), 0755); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Setenv("PATH", os.Getenv("PATH"))
+	os.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{"fuzzer", "requirements.txt", filepath.Join(atherisWheelhouse, "parser-1.0-py3-none-any.whl")} {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := (atherisEngine{}).Prepare(&FuzzitClient{}); err == nil || !strings.Contains(err.Error(), "atheris must be installed") {
+		t.Errorf("was expecting an error without atheris received %v", err)
+	}
+	log, err := ioutil.ReadFile(filepath.Join(dir, "python3.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the requirements are installed from the wheelhouse and atheris isn't installed at job time
+	expected := "-m pip install --disable-pip-version-check --no-index --find-links=wheelhouse -r requirements.txt\n-c import atheris\n"
+	if string(log) != expected {
+		t.Errorf("expected python3 calls %q received %q", expected, log)
+	}
+}
@@ -0,0 +1,36 @@
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFetchTokens(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/createCustomToken", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("api_key") != "key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"Org": "fuzzitdev", "Namespace": "fuzzitdev-ns", "CustomToken": "custom"}`))
+	})
+	mux.HandleFunc("/verifyCustomToken", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"kind": "identitytoolkit#VerifyCustomTokenResponse", "idToken": "id", "refreshToken": "refresh"}`))
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	tokens, err := fetchTokens(ts.Client(), ts.URL, ts.URL+"/verifyCustomToken", "key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the account of the api key comes from the createCustomToken response
+	if tokens.Org != "fuzzitdev" || tokens.Namespace != "fuzzitdev-ns" || tokens.IdToken != "id" || tokens.RefreshToken != "refresh" {
+		t.Errorf("unexpected tokens %+v", tokens)
+	}
+
+	if _, err := fetchTokens(ts.Client(), ts.URL, ts.URL+"/verifyCustomToken", "invalid"); err == nil {
+		t.Error("was expecting an error for an invalid api key")
+	}
+}
@@ -0,0 +1,154 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+)
+
+// ErrNotFound is returned by a Backend when a document or a storage object doesn't exist.
+// The message matches the HTTP status the hosted service returns so existing checks keep working.
+var ErrNotFound = errors.New("404 Not Found")
+
+// Account is the org and namespace the backend credentials belong to
+type Account struct {
+	Org       string
+	Namespace string
+}
+
+// Storage keeps the blobs (seed, corpus, fuzzer and crash artifacts) of a Backend
+type Storage interface {
+	// UploadFile stores the local file filePath at storagePath. filename is the name
+	// the object should be downloaded as
+	UploadFile(filePath string, storagePath string, filename string) error
+	// DownloadFile fetches the object at storagePath to filePath and returns the filename
+	// it was uploaded with (if known)
+	DownloadFile(filePath string, storagePath string) (string, error)
+	// DeleteFile removes the object at storagePath. Deleting an object that doesn't exist isn't an error
+	DeleteFile(storagePath string) error
+}
+
+// Backend is the storage layer behind FuzzitClient. It keeps the target, job and crash
+// documents and the blobs (seed, corpus, fuzzer and crash artifacts). Documents and blobs
+// are addressed with the same layout used by the hosted service:
+// orgs/<org>/targets/<target>/jobs/<job>/crashes/<crash>
+type Backend interface {
+	Storage
+
+	// Authenticate (re)creates the credentials used to access the backend. The returned
+	// Account is empty if the backend doesn't know who the credentials belong to
+	Authenticate() (Account, error)
+
+	// GetDocument returns the document at path or ErrNotFound
+	GetDocument(path string) (map[string]interface{}, error)
+	// ListDocuments returns all the documents of the collection at path. Each document
+	// has its id stored under the "id" key
+	ListDocuments(path string) ([]map[string]interface{}, error)
+	// QueryDocuments returns the documents of the collection at path matching query and the page
+	// token of the next page, empty on the last page
+	QueryDocuments(path string, query Query) ([]map[string]interface{}, string, error)
+	// DeleteDocument removes the document at path but not its sub-collections. Deleting a document
+	// that doesn't exist isn't an error
+	DeleteDocument(path string) error
+
+	GetTarget(org string, targetId string) (*Target, error)
+	SetTarget(org string, target Target) error
+
+	NewJobId(org string, targetId string) string
+	GetJob(org string, targetId string, jobId string) (*Job, error)
+	SetJob(org string, jobId string, job Job) error
+	UpdateJobStatus(org string, targetId string, jobId string, status string) error
+
+	NewCrashId(org string, targetId string, jobId string) string
+	SetCrash(org string, crashId string, crash Crash) error
+	// AddIssueCrash counts the crash issue.LastJobId/issue.LastCrashId in the issue of the target with
+	// signature. The issue is created from issue if it doesn't exist. An issue without an artifact takes
+	// the artifact of issue, if any. It returns the issue after the update
+	AddIssueCrash(org string, targetId string, signature string, issue Issue) (*Issue, error)
+	// ClearIssueArtifact forgets the input of an issue, which is being deleted, so the next crash of the
+	// issue uploads its input again
+	ClearIssueArtifact(org string, targetId string, signature string) error
+
+	// AddJobStats adds a sample to the stats collection of the job
+	AddJobStats(org string, targetId string, jobId string, stats JobStats) error
+	SetJobLogs(org string, targetId string, jobId string, logsId string, logs JobLogs) error
+}
+
+// storageBackend is a Backend whose blobs are kept in a different Storage
+type storageBackend struct {
+	Backend
+	storage Storage
+}
+
+func (b *storageBackend) UploadFile(filePath string, storagePath string, filename string) error {
+	return b.storage.UploadFile(filePath, storagePath, filename)
+}
+
+func (b *storageBackend) DownloadFile(filePath string, storagePath string) (string, error) {
+	return b.storage.DownloadFile(filePath, storagePath)
+}
+
+func (b *storageBackend) DeleteFile(storagePath string) error {
+	return b.storage.DeleteFile(storagePath)
+}
+
+// WithStorage returns backend with its blobs kept in storage instead
+func WithStorage(backend Backend, storage Storage) Backend {
+	return &storageBackend{Backend: backend, storage: storage}
+}
+
+// NewStorage returns the blob storage described by uri.
+// Supported schemes: file:///path/to/dir and s3://host/bucket for any S3-compatible storage
+func NewStorage(uri string) (Storage, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	switch u.Scheme {
+	case "file":
+		storage, err := NewFileBackend(u.Host + u.Path)
+		if err != nil {
+			return nil, err
+		}
+		return storage, nil
+	case "s3":
+		storage, err := NewS3Storage(uri)
+		if err != nil {
+			return nil, err
+		}
+		return storage, nil
+	default:
+		return nil, fmt.Errorf("unsupported storage %s", uri)
+	}
+}
+
+// NewBackend returns the backend described by uri. An empty uri selects the hosted fuzzit service.
+// Supported schemes: file:///path/to/dir and http(s)://host for a self-hosted `fuzzit server`
+func NewBackend(uri string, apiKey string) (Backend, error) {
+	if uri == "" {
+		backend, err := NewFirestoreBackend(apiKey)
+		if err != nil {
+			return nil, err
+		}
+		return backend, nil
+	}
+
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	switch u.Scheme {
+	case "file":
+		backend, err := NewFileBackend(u.Host + u.Path)
+		if err != nil {
+			return nil, err
+		}
+		return backend, nil
+	case "http", "https":
+		return NewHTTPBackend(uri, apiKey), nil
+	default:
+		return nil, fmt.Errorf("unsupported backend %s", uri)
+	}
+}
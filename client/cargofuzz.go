@@ -0,0 +1,74 @@
+package client
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/mholt/archiver"
+)
+
+// fuzzit runs fuzzers on x86_64 linux
+const cargoFuzzTriple = "x86_64-unknown-linux-gnu"
+
+// CargoFuzzTarget locates a fuzz target built with cargo fuzz build under fuzz/target/<triple>/release/ and
+// returns the binary, its target triple and its corpus directory in fuzz/corpus/<target> (empty if it doesn't exist)
+func CargoFuzzTarget(fuzzTarget string) (binary string, triple string, corpus string, err error) {
+	binaries, err := filepath.Glob(filepath.Join("fuzz", "target", "*", "release", fuzzTarget))
+	if err != nil {
+		return "", "", "", err
+	}
+	switch len(binaries) {
+	case 0:
+		return "", "", "", fmt.Errorf("fuzz target %s not found under fuzz/target/<triple>/release/. run cargo fuzz build %s first", fuzzTarget, fuzzTarget)
+	case 1:
+		binary = binaries[0]
+	default:
+		for _, b := range binaries {
+			if strings.Contains(b, cargoFuzzTriple) {
+				binary = b
+			}
+		}
+		if binary == "" {
+			return "", "", "", fmt.Errorf("fuzz target %s was built for several triples: %s", fuzzTarget, strings.Join(binaries, ", "))
+		}
+	}
+	triple = filepath.Base(filepath.Dir(filepath.Dir(binary)))
+
+	corpus = filepath.Join("fuzz", "corpus", fuzzTarget)
+	if isEmpty, err := IsDirEmpty(corpus); err != nil || isEmpty {
+		corpus = ""
+	}
+
+	return binary, triple, corpus, nil
+}
+
+// CargoFuzzHost returns the host image for fuzzers built for triple. cargo fuzz builds with a recent
+// toolchain so the image with the most recent glibc is used
+func CargoFuzzHost(triple string) (string, error) {
+	if triple != cargoFuzzTriple {
+		return "", fmt.Errorf("fuzz targets should be built for %s. Received: %s", cargoFuzzTriple, triple)
+	}
+	return HostToDocker["bionic-llvm7"], nil
+}
+
+// ArchiveCorpus archives the files of a corpus directory in a flat tar.gz, the format expected for the additional corpus
+func ArchiveCorpus(dir string) (string, error) {
+	files, err := listFiles(dir)
+	if err != nil {
+		return "", err
+	}
+
+	prefix, err := uuid.NewRandom()
+	if err != nil {
+		return "", err
+	}
+	tmpfile := filepath.Join(os.TempDir(), prefix.String()+".tar.gz")
+	if err := archiver.NewTarGz().Archive(files, tmpfile); err != nil {
+		return "", err
+	}
+
+	return tmpfile, nil
+}
@@ -0,0 +1,88 @@
+package client
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mholt/archiver"
+)
+
+func TestCargoFuzzTarget(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fuzzit-cargo-fuzz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, _, err := CargoFuzzTarget("parse"); err == nil {
+		t.Error("was expecting an error for a fuzz target that wasn't built")
+	}
+
+	for _, path := range []string{
+		"fuzz/target/x86_64-unknown-linux-gnu/release/parse",
+		"fuzz/target/aarch64-unknown-linux-gnu/release/parse",
+		"fuzz/target/aarch64-unknown-linux-gnu/release/decode",
+		"fuzz/corpus/parse/input",
+	} {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte("input"), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the x86_64 build is picked from several triples
+	binary, triple, corpus, err := CargoFuzzTarget("parse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if binary != "fuzz/target/x86_64-unknown-linux-gnu/release/parse" || triple != cargoFuzzTriple || corpus != "fuzz/corpus/parse" {
+		t.Errorf("unexpected fuzz target %s %s %s", binary, triple, corpus)
+	}
+	if _, err := CargoFuzzHost(triple); err != nil {
+		t.Error(err)
+	}
+
+	// targets without a corpus have none
+	binary, triple, corpus, err = CargoFuzzTarget("decode")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if corpus != "" {
+		t.Errorf("was expecting no corpus received %s", corpus)
+	}
+	if _, err := CargoFuzzHost(triple); err == nil {
+		t.Errorf("was expecting an error for the host of %s", triple)
+	}
+
+	// the corpus is archived flat and added to the fuzzer of local jobs
+	archive, err := ArchiveCorpus("fuzz/corpus/parse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(archive)
+	fuzzerArchive, err := (&FuzzitClient{}).localJobArchive(archive, []string{"fuzz/target/x86_64-unknown-linux-gnu/release/parse"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(fuzzerArchive)
+	if err := archiver.NewTarGz().Unarchive(fuzzerArchive, "extracted"); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{"extracted/fuzzer", "extracted/additional-corpus/input"} {
+		if _, err := os.Stat(path); err != nil {
+			t.Error(err)
+		}
+	}
+}
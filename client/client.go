@@ -1,93 +1,151 @@
 package client
 
 import (
-	"context"
-	"google.golang.org/api/option"
-	"google.golang.org/grpc"
-	"net/http"
 	"time"
-
-	"cloud.google.com/go/firestore"
 )
 
-const FuzzitEndpoint = "https://app.fuzzit.dev"
+// FuzzitEndpoint is the server used for authentication and storage links by the hosted backend
+var FuzzitEndpoint = "https://app.fuzzit.dev"
+
 const Version = "v2.4.77"
 
 type Target struct {
-	Name         string `firestore:"target_name"`
-	PublicCorpus bool   `firestore:"public_corpus"`
+	Name         string `firestore:"target_name" json:"target_name"`
+	PublicCorpus bool   `firestore:"public_corpus" json:"public_corpus"`
 }
 
 type Job struct {
-	TargetId             string    `firestore:"target_id"`
-	Args                 string    `firestore:"args"`
-	Type                 string    `firestore:"type"`
-	Engine               string    `firestore:"engine"`
-	Host                 string    `firestore:"host"`
-	Revision             string    `firestore:"revision"`
-	Branch               string    `firestore:"branch"`
-	CPUs                 string    `firestore:"cpus"`
-	Memory               string    `firestore:"memory"`
-	EnvironmentVariables []string  `firestore:"environment_variables"`
-	Completed            uint16    `firestore:"completed"`
-	Status               string    `firestore:"status"`
-	Namespace            string    `firestore:"namespace"`
-	StartedAt            time.Time `firestore:"started_at,serverTimestamp"`
-	OrgId                string    `firestore:"org_id"`
+	TargetId             string    `firestore:"target_id" json:"target_id"`
+	Args                 string    `firestore:"args" json:"args"`
+	Type                 string    `firestore:"type" json:"type"`
+	Engine               string    `firestore:"engine" json:"engine"`
+	Host                 string    `firestore:"host" json:"host"`
+	Revision             string    `firestore:"revision" json:"revision"`
+	Branch               string    `firestore:"branch" json:"branch"`
+	CPUs                 string    `firestore:"cpus" json:"cpus"`
+	Memory               string    `firestore:"memory" json:"memory"`
+	EnvironmentVariables []string  `firestore:"environment_variables" json:"environment_variables"`
+	Completed            uint16    `firestore:"completed" json:"completed"`
+	Status               string    `firestore:"status" json:"status"`
+	Namespace            string    `firestore:"namespace" json:"namespace"`
+	StartedAt            time.Time `firestore:"started_at,serverTimestamp" json:"started_at"`
+	OrgId                string    `firestore:"org_id" json:"org_id"`
+	// MinimizeRuns are the runs of the fuzzer spent minimizing a crashing input before it's uploaded, 0 disables it
+	MinimizeRuns int `firestore:"minimize_runs" json:"minimize_runs"`
+}
+
+type Crash struct {
+	TargetName string    `firestore:"target_name" json:"target_name"`
+	PodId      string    `firestore:"pod_id" json:"pod_id"`
+	JobId      string    `firestore:"job_id" json:"job_id"`
+	TargetId   string    `firestore:"target_id" json:"target_id"`
+	OrgId      string    `firestore:"org_id" json:"org_id"`
+	ExitCode   uint32    `firestore:"exit_code" json:"exit_code"`
+	Type       string    `firestore:"type" json:"type"`
+	Time       time.Time `firestore:"time,serverTimestamp" json:"time"`
+	V2         bool      `firestore:"v2" json:"v2"`
+	LastLines  string    `firestore:"last_lines" json:"last_lines"`
+	// parsed from the sanitizer report, Go panic or Java exception in LastLines. see ParseCrashReport
+	Sanitizer  string   `firestore:"sanitizer" json:"sanitizer"`
+	BugType    string   `firestore:"bug_type" json:"bug_type"`
+	AccessType string   `firestore:"access_type" json:"access_type"`
+	AccessSize uint32   `firestore:"access_size" json:"access_size"`
+	Frames     []string `firestore:"frames" json:"frames"`
+	// Signature groups the crash in the issues collection of the target, empty if the report couldn't be parsed
+	Signature string `firestore:"signature" json:"signature"`
+	// Artifact is the storage path of the crashing input. Duplicates of an issue point to the input of its first crash
+	Artifact string `firestore:"artifact" json:"artifact"`
+	// MinimizedArtifact is the storage path of the minimized input, if the job minimizes crashes
+	MinimizedArtifact string `firestore:"minimized_artifact" json:"minimized_artifact"`
+}
+
+// Issue groups the crashes of a target with the same signature. Issues are kept in the issues
+// collection of the target with their signature as id
+type Issue struct {
+	Sanitizer string    `firestore:"sanitizer" json:"sanitizer"`
+	BugType   string    `firestore:"bug_type" json:"bug_type"`
+	Frames    []string  `firestore:"frames" json:"frames"`
+	FirstSeen time.Time `firestore:"first_seen,serverTimestamp" json:"first_seen"`
+	LastSeen  time.Time `firestore:"last_seen,serverTimestamp" json:"last_seen"`
+	Count     int64     `firestore:"count" json:"count"`
+	// JobId and CrashId are the crash whose input is uploaded, the other crashes of the issue point to it.
+	// They are empty until an input was uploaded
+	JobId             string `firestore:"job_id" json:"job_id"`
+	CrashId           string `firestore:"crash_id" json:"crash_id"`
+	Artifact          string `firestore:"artifact" json:"artifact"`
+	MinimizedArtifact string `firestore:"minimized_artifact" json:"minimized_artifact"`
+	LastJobId         string `firestore:"last_job_id" json:"last_job_id"`
+	LastCrashId       string `firestore:"last_crash_id" json:"last_crash_id"`
 }
 
-type crash struct {
-	TargetName string    `firestore:"target_name"`
-	PodId      string    `firestore:"pod_id"`
-	JobId      string    `firestore:"job_id"`
-	TargetId   string    `firestore:"target_id"`
-	OrgId      string    `firestore:"org_id"`
-	ExitCode   uint32    `firestore:"exit_code"`
-	Type       string    `firestore:"type"`
-	Time       time.Time `firestore:"time,serverTimestamp"`
-	V2         bool      `firestore:"v2"`
-	LastLines  string    `firestore:"last_lines"`
+// JobStats is a sample of the fuzzer statistics of a job, kept in the stats collection of the job
+type JobStats struct {
+	Time time.Time `firestore:"time,serverTimestamp" json:"time"`
+	// Final is set for the final stats printed at the end of a fuzzing session
+	Final         bool  `firestore:"final" json:"final"`
+	Executions    int64 `firestore:"number_of_executed_units" json:"number_of_executed_units"`
+	ExecsPerSec   int64 `firestore:"average_exec_per_sec" json:"average_exec_per_sec"`
+	PeakRssMb     int64 `firestore:"peak_rss_mb" json:"peak_rss_mb"`
+	NewUnitsAdded int64 `firestore:"new_units_added" json:"new_units_added"`
+	Coverage      int64 `firestore:"cov" json:"cov"`
+	Features      int64 `firestore:"ft" json:"ft"`
+	CorpusSize    int64 `firestore:"corp" json:"corp"`
+}
+
+// JobLogs is a chunk of the output of a job. The chunks are kept in the logs collection of the job and
+// their ids sort in the order they were written
+type JobLogs struct {
+	Time time.Time `firestore:"time,serverTimestamp" json:"time"`
+	// Offset is the number of lines written before this chunk
+	Offset int64    `firestore:"offset" json:"offset"`
+	Lines  []string `firestore:"lines" json:"lines"`
 }
 
 type FuzzitClient struct {
-	Org             string
-	Namespace       string
-	ApiKey          string
-	CustomToken     string
-	Kind            string `json:"kind"`
-	IdToken         string `json:"idToken"`
-	RefreshToken    string `json:"refreshToken"`
-	ExpiresIn       string `json:"expiresIn"`
-	LastRefresh     int64
-	firestoreClient *firestore.Client
-	httpClient      *http.Client
-	currentJob      Job    // this is mainly used by the agent
-	jobId           string // this is mainly used by the agent
-	updateDB        bool   // this is mainly used by the agent
-	fuzzerFilename  string // this is mainly used by the agent
+	Org            string
+	Namespace      string
+	ApiKey         string
+	backend        Backend
+	currentJob     Job    // this is mainly used by the agent
+	jobId          string // this is mainly used by the agent
+	updateDB       bool   // this is mainly used by the agent
+	fuzzerFilename string // this is mainly used by the agent
+	crashId        string // the crash replayed by RunReproduce
 }
 
 func NewFuzzitClient(apiKey string) (*FuzzitClient, error) {
-	ctx := context.Background()
+	backend, err := NewFirestoreBackend(apiKey)
+	if err != nil {
+		return nil, err
+	}
 
+	return NewFuzzitClientWithBackend(apiKey, backend)
+}
+
+func NewFuzzitClientWithBackend(apiKey string, backend Backend) (*FuzzitClient, error) {
 	c := &FuzzitClient{}
-	c.httpClient = &http.Client{Timeout: 120 * time.Second}
 	c.ApiKey = apiKey
+	c.backend = backend
 
-	conn, err := grpc.Dial("firestore.googleapis.com", grpc.WithInsecure())
-	if err != nil {
+	if err := c.refreshToken(); err != nil {
 		return nil, err
 	}
 
-	firestoreClient, err := firestore.NewClient(ctx, "fuzzit-b5fbf", option.WithGRPCConn(conn))
-	c.firestoreClient = firestoreClient
+	return c, nil
+}
+
+func (c *FuzzitClient) refreshToken() error {
+	account, err := c.backend.Authenticate()
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	if err := c.refreshToken(); err != nil {
-		return nil, err
+	if account.Org != "" {
+		c.Org = account.Org
+	}
+	if account.Namespace != "" {
+		c.Namespace = account.Namespace
 	}
 
-	return c, nil
+	return nil
 }
@@ -2,26 +2,23 @@ package client
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/codes"
 	"io"
 	"io/ioutil"
 	"log"
 	"os"
 	"path/filepath"
 	"regexp"
+	"runtime"
 	"strings"
-
-	"cloud.google.com/go/firestore"
+	"time"
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/pkg/stdcopy"
 	"github.com/google/uuid"
 	"github.com/mholt/archiver"
-	"google.golang.org/api/iterator"
 
 	//"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/client"
@@ -29,10 +26,12 @@ import (
 )
 
 var HostToDocker = map[string]string{
-	"stretch-llvm8":  "gcr.io/fuzzit-public/stretch-llvm8:64bdedf",
-	"stretch-llvm9":  "gcr.io/fuzzit-public/stretch-llvm9:4e6f6d3",
-	"bionic-swift51": "gcr.io/fuzzit-public/bionic-swift51:beb0e9b",
-	"bionic-llvm7":   "gcr.io/fuzzit-public/bionic-llvm7:6cf3292",
+	"stretch-llvm8":   "gcr.io/fuzzit-public/stretch-llvm8:64bdedf",
+	"stretch-llvm9":   "gcr.io/fuzzit-public/stretch-llvm9:4e6f6d3",
+	"bionic-swift51":  "gcr.io/fuzzit-public/bionic-swift51:beb0e9b",
+	"bionic-llvm7":    "gcr.io/fuzzit-public/bionic-llvm7:6cf3292",
+	"stretch-python3": "gcr.io/fuzzit-public/stretch-python3:atheris-2.0.7",
+	"bionic-python3":  "gcr.io/fuzzit-public/bionic-python3:atheris-2.0.7",
 }
 
 func (c *FuzzitClient) archiveFiles(files []string) (string, error) {
@@ -67,6 +66,36 @@ func (c *FuzzitClient) archiveFiles(files []string) (string, error) {
 	return fuzzerPath, nil
 }
 
+// agentArchive archives the running fuzzit, which has to be a linux/amd64 build, to run it as the agent of a
+// container. It returns the path of the archive in a temporary directory
+func agentArchive() (string, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+
+	tmpDir, err := ioutil.TempDir("", "fuzzit")
+	if err != nil {
+		return "", err
+	}
+	agentPath := filepath.Join(tmpDir, "fuzzit")
+	if _, err := copyFile(agentPath, executable); err != nil {
+		os.RemoveAll(tmpDir)
+		return "", err
+	}
+	if err := os.Chmod(agentPath, 0755); err != nil {
+		os.RemoveAll(tmpDir)
+		return "", err
+	}
+
+	archivePath := filepath.Join(tmpDir, "fuzzit.tar.gz")
+	if err := archiver.NewTarGz().Archive([]string{agentPath}, archivePath); err != nil {
+		os.RemoveAll(tmpDir)
+		return "", err
+	}
+	return archivePath, nil
+}
+
 func (c *FuzzitClient) DownloadAndExtractCorpus(dst string, target string) error {
 	storagePath := fmt.Sprintf("orgs/%s/targets/%s/corpus.tar.gz", c.Org, target)
 	err := c.downloadAndExtract(dst, storagePath)
@@ -94,110 +123,170 @@ func (c *FuzzitClient) DownloadAndExtractFuzzer(dst string, target string, job s
 	return nil
 }
 
-func (c *FuzzitClient) GetResource(resource string) error {
+// sinceFields are the timestamps the --since filter applies to in each collection
+var sinceFields = map[string]string{
+	"jobs":    "started_at",
+	"crashes": "time",
+	"issues":  "last_seen",
+	"stats":   "time",
+	"logs":    "time",
+}
+
+// GetResource prints a document or a collection in the output format, see printResource.
+// The query only applies to collections
+func (c *FuzzitClient) GetResource(resource string, output string, query Query) error {
 	err := c.refreshToken()
 	if err != nil {
 		return err
 	}
 
-	ctx := context.Background()
 	rootColRef := "orgs/" + c.Org + "/"
 	r := rootColRef + resource
-	if (len(strings.Split(resource, "/")) % 2) == 0 {
-		docRef := c.firestoreClient.Doc(r)
-		if docRef == nil {
-			return fmt.Errorf("invalid resource %s", r)
+	segments := strings.Split(resource, "/")
+	if (len(segments) % 2) == 0 {
+		if !query.IsEmpty() {
+			return fmt.Errorf("filters, ordering and pages only apply to collections")
+		}
+		doc, err := c.backend.GetDocument(r)
+		if err == ErrNotFound {
+			return fmt.Errorf("resource %s doesn't exist", resource)
 		}
-		docsnap, err := docRef.Get(ctx)
 		if err != nil {
 			return err
 		}
-		if !docsnap.Exists() {
-			return fmt.Errorf("resource %s doesn't exist", resource)
+		// documents are printed with their id like the documents of a collection
+		doc["id"] = segments[len(segments)-1]
+
+		return printResource(os.Stdout, output, segments[len(segments)-2], doc)
+	} else {
+		collection := segments[len(segments)-1]
+		if !query.Since.IsZero() && query.SinceField == "" {
+			query.SinceField = sinceFields[collection]
+			if query.SinceField == "" {
+				return fmt.Errorf("--since isn't supported for %s", collection)
+			}
+		}
+		// firestore orders by the field of an inequality filter first
+		if !query.Since.IsZero() && query.OrderBy != "" && strings.TrimPrefix(query.OrderBy, "-") != query.SinceField {
+			return fmt.Errorf("--since on %s can only be combined with --order-by %s or -%s", collection, query.SinceField, query.SinceField)
 		}
 
-		jsonString, err := json.MarshalIndent(docsnap.Data(), "", " ")
+		var docs []map[string]interface{}
+		nextPageToken := ""
+		if query.IsEmpty() {
+			docs, err = c.backend.ListDocuments(r)
+		} else {
+			docs, nextPageToken, err = c.backend.QueryDocuments(r, query)
+		}
 		if err != nil {
 			return err
 		}
-		fmt.Println(string(jsonString))
-		return nil
-	} else {
-		iter := c.firestoreClient.Collection(r).Documents(ctx)
-		querySize := 0
-		defer iter.Stop()
-
-		for {
-			doc, err := iter.Next()
-			if err == iterator.Done {
-				break
-			}
-			if err != nil {
-				return err
-			}
-			data := doc.Data()
-			data["id"] = doc.Ref.ID
-			jsonString, err := json.MarshalIndent(data, "", " ")
-			if err != nil {
-				return err
-			}
-			fmt.Println(string(jsonString))
-			querySize += 1
+		if docs == nil {
+			// an empty collection is printed as an empty list
+			docs = []map[string]interface{}{}
 		}
-		if querySize == 0 {
-			return fmt.Errorf("no resources for %s", resource)
+
+		if err := printResource(os.Stdout, output, collection, docs); err != nil {
+			return err
+		}
+		// the token of the next page goes to stderr so the output keeps the shape of the collection
+		if nextPageToken != "" {
+			log.Printf("more results with --page-token %s", nextPageToken)
 		}
 		return nil
 	}
 }
 
-func (c *FuzzitClient) CreateTarget(target Target, seedPath string, skipIsExists bool) (*firestore.DocumentRef, error) {
+func (c *FuzzitClient) CreateTarget(target Target, seedPath string, skipIsExists bool) error {
 	err := c.refreshToken()
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	re := regexp.MustCompile("^[a-z0-9-]+$")
 	if !re.MatchString(target.Name) {
-		return nil, fmt.Errorf("target can only contain lowercase characetrs, numbers and hypens")
+		return fmt.Errorf("target can only contain lowercase characetrs, numbers and hypens")
 	}
 
-	ctx := context.Background()
-	docRef := c.firestoreClient.Doc("orgs/" + c.Org + "/targets/" + target.Name)
-	_, err = docRef.Get(ctx)
-	if err != nil && grpc.Code(err) != codes.NotFound {
-		return nil, err
+	_, err = c.backend.GetTarget(c.Org, target.Name)
+	if err != nil && err != ErrNotFound {
+		return err
 	} else if err == nil && skipIsExists {
-		return docRef, nil
+		return nil
 	} else if err == nil && !skipIsExists {
-		return nil, fmt.Errorf("target %s already exist", target.Name)
+		return fmt.Errorf("target %s already exist", target.Name)
 	}
 
 	if seedPath != "" {
 		storagePath := fmt.Sprintf("orgs/%s/targets/%s/seed", c.Org, target.Name)
 		err := c.uploadFile(seedPath, storagePath, "seed.tar.gz")
 		if err != nil {
-			return nil, err
+			return err
 		}
 	}
 
-	_, err = docRef.Set(ctx, target)
+	err = c.backend.SetTarget(c.Org, target)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	return docRef, nil
+	return nil
 }
 
-func (c *FuzzitClient) CreateLocalJob(jobConfig Job, files []string) error {
-	ctx := context.Background()
-	cli, err := client.NewClientWithOpts(client.FromEnv)
+// containerBackendConfig returns the environment variables and volumes the agent needs to reach backend
+// from a container. envName is the variable the backend uri is passed in (FUZZIT_BACKEND or FUZZIT_STORAGE)
+func containerBackendConfig(backend interface{}, envName string) ([]string, []string) {
+	var env []string
+	var binds []string
+
+	switch b := backend.(type) {
+	case *storageBackend:
+		env, binds = containerBackendConfig(b.Backend, "FUZZIT_BACKEND")
+		storageEnv, storageBinds := containerBackendConfig(b.storage, "FUZZIT_STORAGE")
+		env = append(env, storageEnv...)
+		binds = append(binds, storageBinds...)
+	case *FileBackend:
+		// the agent inside the container reads the corpus from the same directory
+		env = append(env, envName+"=file://"+b.Root)
+		binds = append(binds, b.Root+":"+b.Root)
+	case *HTTPBackend:
+		env = append(env, envName+"="+b.Endpoint)
+	case *S3Storage:
+		env = append(env,
+			envName+"="+b.uri,
+			"AWS_ACCESS_KEY_ID="+b.AccessKey,
+			"AWS_SECRET_ACCESS_KEY="+b.SecretKey)
+	}
+
+	return env, binds
+}
+
+// localJobArchive archives the fuzzer files for a local job. The files of the additionalCorpus archive, if
+// set, are added in the additional-corpus directory the agent replays along with the corpus of the target
+func (c *FuzzitClient) localJobArchive(additionalCorpus string, files []string) (string, error) {
+	if additionalCorpus == "" {
+		return c.archiveFiles(files)
+	}
+	if strings.HasSuffix(files[0], ".tar.gz") {
+		return "", fmt.Errorf("the additional corpus can't be added to the fuzzer archive %s", files[0])
+	}
+
+	tmpDir, err := ioutil.TempDir("", "fuzzit")
 	if err != nil {
-		return err
+		return "", err
 	}
-	cli.NegotiateAPIVersion(ctx)
+	defer os.RemoveAll(tmpDir)
+	corpusDir := filepath.Join(tmpDir, "additional-corpus")
+	if err := archiver.Unarchive(additionalCorpus, corpusDir); err != nil {
+		return "", err
+	}
+	return c.archiveFiles(append(append([]string{}, files...), corpusDir))
+}
 
-	fuzzerPath, err := c.archiveFiles(files)
+// CreateLocalJob runs a regression of the fuzzer files in a local container, see localJobArchive for
+// additionalCorpus
+func (c *FuzzitClient) CreateLocalJob(jobConfig Job, additionalCorpus string, files []string) error {
+	fuzzerPath, err := c.localJobArchive(additionalCorpus, files)
 	if err != nil {
 		return err
 	}
@@ -207,6 +296,21 @@ func (c *FuzzitClient) CreateLocalJob(jobConfig Job, files []string) error {
 		return err
 	}
 
+	return c.runAgentContainer(jobConfig, fuzzer,
+		fmt.Sprintf(`--engine "%s" --type regression --args "%s" %s %s`, jobConfig.Engine, jobConfig.Args, c.Org, jobConfig.TargetId))
+}
+
+// runAgentContainer runs the agent with agentArgs in the host image of jobConfig. archive, if set, is
+// extracted to the working directory of the agent. The released agent of this version is downloaded
+// unless archive holds an executable fuzzit
+func (c *FuzzitClient) runAgentContainer(jobConfig Job, archive io.Reader, agentArgs string) error {
+	ctx := context.Background()
+	cli, err := client.NewClientWithOpts(client.FromEnv)
+	if err != nil {
+		return err
+	}
+	cli.NegotiateAPIVersion(ctx)
+
 	log.Printf("Pulling container %s\n", jobConfig.Host)
 	reader, err := cli.ImagePull(ctx, jobConfig.Host, types.ImagePullOptions{})
 	if err != nil {
@@ -216,42 +320,49 @@ func (c *FuzzitClient) CreateLocalJob(jobConfig Job, files []string) error {
 	if err != nil {
 		return err
 	}
+	env := []string{
+		"ARGS=" + jobConfig.Args,
+		"LD_LIBRARY_PATH=/app",
+		"FUZZIT_API_KEY=" + c.ApiKey,
+	}
+	backendEnv, binds := containerBackendConfig(c.backend, "FUZZIT_BACKEND")
+	env = append(env, backendEnv...)
+
 	log.Println("Creating container")
 	createdContainer, err := cli.ContainerCreate(ctx,
 		&container.Config{
-			Env: append(
-				[]string{
-					"ARGS=" + jobConfig.Args,
-					"LD_LIBRARY_PATH=/app",
-					"FUZZIT_API_KEY=" + c.ApiKey,
-				},
-				jobConfig.EnvironmentVariables...),
+			Env:        append(env, jobConfig.EnvironmentVariables...),
 			Image:      jobConfig.Host,
 			WorkingDir: "/app",
 			Cmd: []string{
 				"/bin/sh",
 				"-c",
 				fmt.Sprintf(`cd /app
-echo "Downloading fuzzit cli/agent..."
-wget -q -O fuzzit https://github.com/fuzzitdev/fuzzit/releases/download/%s/fuzzit_Linux_x86_64
-chmod a+x fuzzit
-./fuzzit run --engine "%s" --type regression --args "%s" %s %s`, Version, jobConfig.Engine, jobConfig.Args, c.Org, jobConfig.TargetId),
+if [ ! -x fuzzit ]; then
+  echo "Downloading fuzzit cli/agent..."
+  wget -q -O fuzzit https://github.com/fuzzitdev/fuzzit/releases/download/%s/fuzzit_Linux_x86_64
+  chmod a+x fuzzit
+fi
+./fuzzit run %s`, Version, agentArgs),
 			},
 			AttachStdin: true,
 		},
 		&container.HostConfig{
 			CapAdd: []string{"SYS_PTRACE"},
+			Binds:  binds,
 		}, nil, "")
 	if err != nil {
 		return err
 	}
 
-	log.Println("Uploading fuzzer to container")
-	err = cli.CopyToContainer(ctx, createdContainer.ID, "/app", fuzzer, types.CopyToContainerOptions{
-		AllowOverwriteDirWithFile: true,
-	})
-	if err != nil {
-		return err
+	if archive != nil {
+		log.Println("Uploading fuzzer to container")
+		err = cli.CopyToContainer(ctx, createdContainer.ID, "/app", archive, types.CopyToContainerOptions{
+			AllowOverwriteDirWithFile: true,
+		})
+		if err != nil {
+			return err
+		}
 	}
 
 	log.Println("Starting the container")
@@ -294,36 +405,89 @@ chmod a+x fuzzit
 	return nil
 }
 
-func (c *FuzzitClient) CreateJob(jobConfig Job, additionalCorpus string, files []string) (*firestore.DocumentRef, error) {
+// Reproduce replays a crash in the host image of its job with the environment and args of the job
+func (c *FuzzitClient) Reproduce(targetId string, jobId string, crashId string) error {
+	if err := c.refreshToken(); err != nil {
+		return err
+	}
+
+	job, err := c.backend.GetJob(c.Org, targetId, jobId)
+	if err != nil {
+		return notFound(err, "job %s/%s doesn't exist", targetId, jobId)
+	}
+	if _, err := c.backend.GetDocument(fmt.Sprintf("orgs/%s/targets/%s/jobs/%s/crashes/%s", c.Org, targetId, jobId, crashId)); err != nil {
+		return notFound(err, "crash %s/%s/%s doesn't exist", targetId, jobId, crashId)
+	}
+
+	engine, err := GetEngine(job.Engine)
+	if err != nil {
+		return err
+	}
+	if job.Host == "" {
+		job.Host = engine.DefaultHost()
+	}
+	job.TargetId = targetId
+
+	// the agent of this build is sure to support --crash. other platforms can't run in the container so it
+	// downloads the released agent of this version, like for local jobs
+	var agent io.Reader
+	if runtime.GOOS == "linux" && runtime.GOARCH == "amd64" {
+		agentPath, err := agentArchive()
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(filepath.Dir(agentPath))
+		agentFile, err := os.Open(agentPath)
+		if err != nil {
+			return err
+		}
+		defer agentFile.Close()
+		agent = agentFile
+	} else {
+		log.Printf("fuzzit runs on %s/%s. using the released linux agent %s in the container", runtime.GOOS, runtime.GOARCH, Version)
+	}
+
+	err = c.runAgentContainer(*job, agent,
+		fmt.Sprintf(`--engine "%s" --args "%s" --crash %s %s %s %s`, job.Engine, job.Args, crashId, c.Org, targetId, jobId))
+	if err == nil {
+		log.Println("the crash didn't reproduce")
+	}
+	return err
+}
+
+func (c *FuzzitClient) CreateJob(jobConfig Job, additionalCorpus string, files []string) (string, error) {
 	err := c.refreshToken()
 	if err != nil {
-		return nil, err
+		return "", err
 	}
-	ctx := context.Background()
-	collectionRef := c.firestoreClient.Collection("orgs/" + c.Org + "/targets/" + jobConfig.TargetId + "/jobs")
 	jobConfig.Completed = 0
 	jobConfig.OrgId = c.Org
 	jobConfig.Namespace = c.Namespace
 	jobConfig.Status = "queued"
 
-	jobRef := collectionRef.NewDoc()
+	engine, err := GetEngine(jobConfig.Engine)
+	if err != nil {
+		return "", err
+	}
+
+	jobId := c.backend.NewJobId(c.Org, jobConfig.TargetId)
 
 	fuzzerPath := files[0]
 	filename := filepath.Base(fuzzerPath)
-	if !strings.HasSuffix(filename, ".tar.gz") && jobConfig.Engine != "go-fuzz" {
+	if !strings.HasSuffix(filename, ".tar.gz") && engine.RawFuzzer() == "" {
 		tmpDir, err := ioutil.TempDir("", "fuzzit")
 		if err != nil {
-			return nil, err
+			return "", err
 		}
 		dstPath := filepath.Join(tmpDir, "fuzzer")
 		_, err = copyFile(dstPath, fuzzerPath)
 		if err != nil {
-			return nil, err
+			return "", err
 		}
 
 		prefix, err := uuid.NewRandom()
 		if err != nil {
-			return nil, err
+			return "", err
 		}
 		filesToArchive := append([]string{dstPath}, files[1:]...)
 
@@ -331,37 +495,136 @@ func (c *FuzzitClient) CreateJob(jobConfig Job, additionalCorpus string, files [
 		z := archiver.NewTarGz()
 		err = z.Archive(filesToArchive, tmpfile)
 		if err != nil {
-			return nil, err
+			return "", err
 		}
 		fuzzerPath = tmpfile
 	}
 
-	storagePath := fmt.Sprintf("orgs/%s/targets/%s/jobs/%s/fuzzer", c.Org, jobConfig.TargetId, jobRef.ID)
+	storagePath := fmt.Sprintf("orgs/%s/targets/%s/jobs/%s/fuzzer", c.Org, jobConfig.TargetId, jobId)
 	log.Println("Uploading fuzzer...")
 	err = c.uploadFile(fuzzerPath, storagePath, "fuzzer.tar.gz")
 	if err != nil {
-		return nil, err
+		return "", err
 	}
 
 	if additionalCorpus != "" {
 		log.Println("Uploading additional corpus...")
 		err = c.uploadFile(
 			additionalCorpus,
-			fmt.Sprintf("orgs/%s/targets/%s/jobs/%s/additional-corpus", c.Org, jobConfig.TargetId, jobRef.ID),
+			fmt.Sprintf("orgs/%s/targets/%s/jobs/%s/additional-corpus", c.Org, jobConfig.TargetId, jobId),
 			filepath.Base(additionalCorpus))
 		if err != nil {
-			return nil, err
+			return "", err
 		}
 	}
 
 	log.Println("Starting job")
-	_, err = jobRef.Set(ctx, jobConfig)
+	err = c.backend.SetJob(c.Org, jobId, jobConfig)
 	if err != nil {
 		log.Printf("Please check that the target '%s' exists and you have sufficiant permissions",
 			jobConfig.TargetId)
-		return nil, err
+		return "", err
+	}
+
+	log.Printf("Job %s started succesfully\n", jobId)
+	return jobId, nil
+}
+
+const cancelPollInterval = 10 * time.Second
+
+// jobIsRunning is true while an agent may still pick up or update the job
+func jobIsRunning(status string) bool {
+	return status == "queued" || status == "in progress"
+}
+
+// CancelJobs sets the jobs as cancelled and waits up to timeout for their agents to stop the fuzzers.
+// Without jobIds all the queued and in progress jobs of the target are cancelled. A job which can't be
+// cancelled doesn't stop the others, the errors are returned together at the end
+func (c *FuzzitClient) CancelJobs(targetId string, jobIds []string, timeout time.Duration) error {
+	err := c.refreshToken()
+	if err != nil {
+		return err
 	}
 
-	log.Printf("Job %s started succesfully\n", jobRef.ID)
-	return jobRef, nil
+	if jobIds == nil {
+		docs, err := c.backend.ListDocuments(fmt.Sprintf("orgs/%s/targets/%s/jobs", c.Org, targetId))
+		if err != nil {
+			return err
+		}
+		for _, doc := range docs {
+			jobId, err := documentId(doc)
+			if err != nil {
+				return err
+			}
+			if status, _ := doc["status"].(string); jobIsRunning(status) {
+				jobIds = append(jobIds, jobId)
+			}
+		}
+		if len(jobIds) == 0 {
+			log.Printf("no running jobs for %s", targetId)
+			return nil
+		}
+	}
+
+	var errs []string
+	var inProgress []string
+	for _, jobId := range jobIds {
+		job, err := c.backend.GetJob(c.Org, targetId, jobId)
+		if err == ErrNotFound {
+			errs = append(errs, fmt.Sprintf("job %s/%s doesn't exist", targetId, jobId))
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("job %s/%s: %v", targetId, jobId, err))
+			continue
+		}
+		if !jobIsRunning(job.Status) {
+			errs = append(errs, fmt.Sprintf("job %s/%s already finished with status %s", targetId, jobId, job.Status))
+			continue
+		}
+
+		if err := c.backend.UpdateJobStatus(c.Org, targetId, jobId, "cancelled"); err != nil {
+			errs = append(errs, fmt.Sprintf("job %s/%s: %v", targetId, jobId, err))
+			continue
+		}
+		log.Printf("Job %s/%s cancelled", targetId, jobId)
+		// queued jobs are acknowledged once an agent picks them up
+		if job.Status == "in progress" {
+			inProgress = append(inProgress, jobId)
+		}
+	}
+
+	deadline := time.Now().Add(timeout)
+	for len(inProgress) > 0 {
+		log.Printf("waiting for %d job(s) to stop...", len(inProgress))
+		time.Sleep(cancelPollInterval)
+		if err := c.refreshToken(); err != nil {
+			return err
+		}
+
+		var stillRunning []string
+		for _, jobId := range inProgress {
+			job, err := c.backend.GetJob(c.Org, targetId, jobId)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("job %s/%s: %v", targetId, jobId, err))
+				continue
+			}
+			if job.Status == "cancelled" {
+				stillRunning = append(stillRunning, jobId)
+			} else {
+				log.Printf("Job %s/%s stopped with status %s", targetId, jobId, job.Status)
+			}
+		}
+		inProgress = stillRunning
+
+		if len(inProgress) > 0 && time.Now().After(deadline) {
+			errs = append(errs, fmt.Sprintf("timed out waiting for the agent to stop %s", strings.Join(inProgress, ",")))
+			break
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "\n"))
+	}
+	return nil
 }
@@ -92,7 +92,7 @@ func TestCreateLocalJob(t *testing.T) {
 			newJob.Type = "regression"
 			newJob.TargetId = tc.target
 			newJob.Host = "gcr.io/fuzzit-public/stretch-llvm8:64bdedf"
-			err := tc.client.CreateLocalJob(newJob, []string{"testdata/fuzzer.tar.gz"})
+			err := tc.client.CreateLocalJob(newJob, "", []string{"testdata/fuzzer.tar.gz"})
 			if err != nil {
 				if err.Error() != tc.err {
 					t.Errorf("was expecting %s received %s", tc.err, err.Error())
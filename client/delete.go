@@ -0,0 +1,223 @@
+package client
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// jobCollections are the sub-collections of a job document
+var jobCollections = []string{"crashes", "stats", "logs"}
+
+// deletion lists the documents to delete with their storage objects. Documents of sub-collections are
+// listed before their parent so a failed deletion can be run again
+type deletion struct {
+	// issues are the target/signature of the issues whose input is deleted
+	issues    []string
+	documents []deletedDocument
+}
+
+// deletedDocument is a document and the storage objects stored with it
+type deletedDocument struct {
+	path    string
+	objects []string
+}
+
+func (d *deletion) add(path string, objects ...string) {
+	d.documents = append(d.documents, deletedDocument{path: path, objects: objects})
+}
+
+// documentId returns the id of a document listed from a collection
+func documentId(doc map[string]interface{}) (string, error) {
+	id, ok := doc["id"].(string)
+	if !ok || id == "" {
+		return "", fmt.Errorf("document without an id: %v", doc)
+	}
+	return id, nil
+}
+
+// planCrashDeletion deletes the crash at crashPath with its inputs. If the input is the one of the issue of
+// the crash the issue forgets it, so the next crash of the issue uploads its input again
+func (c *FuzzitClient) planCrashDeletion(targetId string, crashPath string, crash map[string]interface{}, d *deletion) error {
+	if signature, _ := crash["signature"].(string); signature != "" {
+		issue, err := c.backend.GetDocument(fmt.Sprintf("orgs/%s/targets/%s/issues/%s", c.Org, targetId, signature))
+		if err != nil && err != ErrNotFound {
+			return err
+		}
+		if artifact, _ := issue["artifact"].(string); artifact == crashPath {
+			d.issues = append(d.issues, targetId+"/"+signature)
+		}
+	}
+
+	// crash artifacts are stored next to the crash document
+	d.add(crashPath, crashPath, crashPath+"-minimized")
+	return nil
+}
+
+func (c *FuzzitClient) planJobDeletion(targetId string, jobId string, d *deletion) error {
+	jobPath := fmt.Sprintf("orgs/%s/targets/%s/jobs/%s", c.Org, targetId, jobId)
+	for _, collection := range jobCollections {
+		docs, err := c.backend.ListDocuments(jobPath + "/" + collection)
+		if err != nil {
+			return err
+		}
+		for _, doc := range docs {
+			id, err := documentId(doc)
+			if err != nil {
+				return err
+			}
+			docPath := jobPath + "/" + collection + "/" + id
+			if collection == "crashes" {
+				if err := c.planCrashDeletion(targetId, docPath, doc, d); err != nil {
+					return err
+				}
+				continue
+			}
+			d.add(docPath)
+		}
+	}
+
+	d.add(jobPath, jobPath+"/fuzzer", jobPath+"/additional-corpus", jobPath+"/workdir.tar.gz")
+	return nil
+}
+
+func (c *FuzzitClient) planTargetDeletion(targetId string, d *deletion) error {
+	targetPath := fmt.Sprintf("orgs/%s/targets/%s", c.Org, targetId)
+	jobs, err := c.backend.ListDocuments(targetPath + "/jobs")
+	if err != nil {
+		return err
+	}
+	for _, job := range jobs {
+		jobId, err := documentId(job)
+		if err != nil {
+			return err
+		}
+		if status, _ := job["status"].(string); jobIsRunning(status) {
+			return fmt.Errorf("job %s/%s is %s. cancel it before deleting the target", targetId, jobId, status)
+		}
+		if err := c.planJobDeletion(targetId, jobId, d); err != nil {
+			return err
+		}
+	}
+
+	issues, err := c.backend.ListDocuments(targetPath + "/issues")
+	if err != nil {
+		return err
+	}
+	for _, issue := range issues {
+		signature, err := documentId(issue)
+		if err != nil {
+			return err
+		}
+		d.add(targetPath + "/issues/" + signature)
+	}
+
+	d.add(targetPath, targetPath+"/corpus.tar.gz", targetPath+"/seed")
+	return nil
+}
+
+// runDeletion deletes each document right after its storage objects, so an interrupted deletion doesn't
+// leave documents pointing to deleted objects nor objects without their document
+func (c *FuzzitClient) runDeletion(d *deletion, dryRun bool) error {
+	// issues forget their input before it's deleted so they never point to a missing input
+	for _, issue := range d.issues {
+		if dryRun {
+			fmt.Printf("issue input %s\n", issue)
+			continue
+		}
+		split := strings.SplitN(issue, "/", 2)
+		if err := c.backend.ClearIssueArtifact(c.Org, split[0], split[1]); err != nil && err != ErrNotFound {
+			return err
+		}
+	}
+
+	objects := 0
+	for _, document := range d.documents {
+		for _, object := range document.objects {
+			objects++
+			if dryRun {
+				fmt.Printf("storage %s\n", object)
+				continue
+			}
+			if err := c.backend.DeleteFile(object); err != nil {
+				return err
+			}
+		}
+		if dryRun {
+			fmt.Printf("document %s\n", document.path)
+			continue
+		}
+		if err := c.backend.DeleteDocument(document.path); err != nil {
+			return err
+		}
+	}
+
+	if !dryRun {
+		log.Printf("deleted %d documents and %d storage objects", len(d.documents), objects)
+	}
+	return nil
+}
+
+// DeleteTarget deletes a target with its jobs, crashes, issues, corpus and seed. With dryRun the documents and
+// storage objects are only printed
+func (c *FuzzitClient) DeleteTarget(targetId string, dryRun bool) error {
+	if err := c.refreshToken(); err != nil {
+		return err
+	}
+
+	if _, err := c.backend.GetTarget(c.Org, targetId); err == ErrNotFound {
+		return fmt.Errorf("target %s doesn't exist", targetId)
+	} else if err != nil {
+		return err
+	}
+
+	d := &deletion{}
+	if err := c.planTargetDeletion(targetId, d); err != nil {
+		return err
+	}
+	return c.runDeletion(d, dryRun)
+}
+
+// DeleteJob deletes a job with its crashes, fuzzer and additional corpus
+func (c *FuzzitClient) DeleteJob(targetId string, jobId string, dryRun bool) error {
+	if err := c.refreshToken(); err != nil {
+		return err
+	}
+
+	job, err := c.backend.GetJob(c.Org, targetId, jobId)
+	if err == ErrNotFound {
+		return fmt.Errorf("job %s/%s doesn't exist", targetId, jobId)
+	} else if err != nil {
+		return err
+	}
+	if jobIsRunning(job.Status) {
+		return fmt.Errorf("job %s/%s is %s. cancel it before deleting it", targetId, jobId, job.Status)
+	}
+
+	d := &deletion{}
+	if err := c.planJobDeletion(targetId, jobId, d); err != nil {
+		return err
+	}
+	return c.runDeletion(d, dryRun)
+}
+
+// DeleteCrash deletes a crash and its artifact
+func (c *FuzzitClient) DeleteCrash(targetId string, jobId string, crashId string, dryRun bool) error {
+	if err := c.refreshToken(); err != nil {
+		return err
+	}
+
+	crashPath := fmt.Sprintf("orgs/%s/targets/%s/jobs/%s/crashes/%s", c.Org, targetId, jobId, crashId)
+	crash, err := c.backend.GetDocument(crashPath)
+	if err == ErrNotFound {
+		return fmt.Errorf("crash %s/%s/%s doesn't exist", targetId, jobId, crashId)
+	} else if err != nil {
+		return err
+	}
+
+	d := &deletion{}
+	if err := c.planCrashDeletion(targetId, crashPath, crash, d); err != nil {
+		return err
+	}
+	return c.runDeletion(d, dryRun)
+}
@@ -0,0 +1,54 @@
+package client
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDeleteTarget(t *testing.T) {
+	c, backend, dir, cleanup := newFileClient(t)
+	defer cleanup()
+
+	if err := c.CreateTarget(Target{Name: "parse-complex"}, "testdata/fuzzer", false); err != nil {
+		t.Fatal(err)
+	}
+	jobId, err := c.CreateJob(Job{TargetId: "parse-complex", Engine: "libfuzzer", Type: "fuzzing"}, "", []string{"testdata/fuzzer"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := backend.UploadFile("testdata/fuzzer", "orgs/fuzzitdev/targets/parse-complex/jobs/"+jobId+"/crashes/crash", "crash"); err != nil {
+		t.Fatal(err)
+	}
+	if err := backend.SetCrash("fuzzitdev", "crash", Crash{TargetId: "parse-complex", JobId: jobId}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.DeleteTarget("parse-complex", false); err == nil {
+		t.Error("was expecting an error when deleting a target with a queued job")
+	}
+	if err := backend.UpdateJobStatus("fuzzitdev", "parse-complex", jobId, "pass"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.DeleteTarget("parse-complex", true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := backend.GetTarget("fuzzitdev", "parse-complex"); err != nil {
+		t.Errorf("dry run deleted the target: %v", err)
+	}
+
+	if err := c.DeleteTarget("parse-complex", false); err != nil {
+		t.Fatal(err)
+	}
+	var left []string
+	filepath.Walk(filepath.Join(dir, "orgs", "fuzzitdev", "targets"), func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			left = append(left, path)
+		}
+		return nil
+	})
+	if len(left) != 0 {
+		t.Errorf("expected nothing left received %v", left)
+	}
+}
@@ -0,0 +1,121 @@
+package client
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// notFound replaces ErrNotFound with a message naming what is missing
+func notFound(err error, format string, a ...interface{}) error {
+	if err == ErrNotFound {
+		return fmt.Errorf(format, a...)
+	}
+	return err
+}
+
+// DownloadCorpus extracts the corpus of a target to dst/corpus
+func (c *FuzzitClient) DownloadCorpus(dst string, targetId string) error {
+	if err := c.refreshToken(); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dst, 0755); err != nil {
+		return err
+	}
+
+	c.fuzzerFilename = ""
+	if err := c.DownloadAndExtractCorpus(dst, targetId); err != nil {
+		return notFound(err, "target %s has no corpus yet", targetId)
+	}
+	log.Printf("corpus of %s extracted to %s", targetId, filepath.Join(dst, "corpus"))
+	return nil
+}
+
+// DownloadSeed extracts the seed corpus of a target to dst
+func (c *FuzzitClient) DownloadSeed(dst string, targetId string) error {
+	if err := c.refreshToken(); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dst, 0755); err != nil {
+		return err
+	}
+
+	c.fuzzerFilename = ""
+	if err := c.DownloadAndExtractSeed(dst, targetId); err != nil {
+		return notFound(err, "target %s has no seed corpus", targetId)
+	}
+	log.Printf("seed of %s extracted to %s", targetId, dst)
+	return nil
+}
+
+// DownloadFuzzer extracts the fuzzer of a job to dst
+func (c *FuzzitClient) DownloadFuzzer(dst string, targetId string, jobId string) error {
+	if err := c.refreshToken(); err != nil {
+		return err
+	}
+
+	// the engine tells how the fuzzer was archived
+	job, err := c.backend.GetJob(c.Org, targetId, jobId)
+	if err != nil {
+		return notFound(err, "job %s/%s doesn't exist", targetId, jobId)
+	}
+	c.currentJob = *job
+	if err := os.MkdirAll(dst, 0755); err != nil {
+		return err
+	}
+
+	c.fuzzerFilename = ""
+	if err := c.DownloadAndExtractFuzzer(dst, targetId, jobId); err != nil {
+		return notFound(err, "job %s/%s has no fuzzer", targetId, jobId)
+	}
+	log.Printf("fuzzer of %s/%s extracted to %s", targetId, jobId, dst)
+	return nil
+}
+
+// crashArtifact returns the storage paths of the input of a crash and of its minimized version, empty if
+// it wasn't minimized. Duplicate crashes point to the input of the first crash of their issue. Inputs are
+// stored next to their crash document otherwise
+func (c *FuzzitClient) crashArtifact(targetId string, jobId string, crashId string) (string, string, error) {
+	crashPath := fmt.Sprintf("orgs/%s/targets/%s/jobs/%s/crashes/%s", c.Org, targetId, jobId, crashId)
+	crash, err := c.backend.GetDocument(crashPath)
+	if err == ErrNotFound {
+		return crashPath, "", nil
+	} else if err != nil {
+		return "", "", err
+	}
+	minimized, _ := crash["minimized_artifact"].(string)
+	if artifact, _ := crash["artifact"].(string); artifact != "" {
+		return artifact, minimized, nil
+	}
+	return crashPath, minimized, nil
+}
+
+// DownloadCrash downloads the input of a crash to dst/crash-<crash> and returns its path. The minimized
+// input, if any, is downloaded to dst/crash-<crash>-minimized
+func (c *FuzzitClient) DownloadCrash(dst string, targetId string, jobId string, crashId string) (string, error) {
+	if err := c.refreshToken(); err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dst, 0755); err != nil {
+		return "", err
+	}
+
+	artifact, minimized, err := c.crashArtifact(targetId, jobId, crashId)
+	if err != nil {
+		return "", notFound(err, "crash %s/%s/%s doesn't exist", targetId, jobId, crashId)
+	}
+	crashPath := filepath.Join(dst, "crash-"+crashId)
+	if _, err := c.backend.DownloadFile(crashPath, artifact); err != nil {
+		os.Remove(crashPath)
+		return "", notFound(err, "crash %s/%s/%s doesn't exist", targetId, jobId, crashId)
+	}
+	log.Printf("crash %s/%s/%s downloaded to %s", targetId, jobId, crashId, crashPath)
+	if minimized != "" {
+		if _, err := c.backend.DownloadFile(crashPath+"-minimized", minimized); err != nil {
+			return "", err
+		}
+		log.Printf("minimized crash downloaded to %s", crashPath+"-minimized")
+	}
+	return crashPath, nil
+}
@@ -0,0 +1,50 @@
+package client
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDownload(t *testing.T) {
+	c, backend, dir, cleanup := newFileClient(t)
+	defer cleanup()
+
+	if err := c.CreateTarget(Target{Name: "parse-complex"}, "testdata/fuzzer.tar.gz", false); err != nil {
+		t.Fatal(err)
+	}
+	jobId, err := c.CreateJob(Job{TargetId: "parse-complex", Engine: "libfuzzer", Type: "fuzzing"}, "", []string{"testdata/fuzzer"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := backend.UploadFile("testdata/fuzzer.tar.gz", "orgs/fuzzitdev/targets/parse-complex/jobs/"+jobId+"/crashes/crash", "crash-parse-complex"); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := filepath.Join(dir, "download")
+	if err := c.DownloadCorpus(dst, "parse-complex"); err == nil || err.Error() != "target parse-complex has no corpus yet" {
+		t.Errorf("was expecting a missing corpus received %v", err)
+	}
+	if err := c.DownloadSeed(filepath.Join(dst, "seed"), "parse-complex"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.DownloadFuzzer(filepath.Join(dst, "fuzzer"), "parse-complex", jobId); err != nil {
+		t.Fatal(err)
+	}
+	crashPath, err := c.DownloadCrash(dst, "parse-complex", jobId, "crash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.DownloadCrash(dst, "parse-complex", jobId, "invalid-crash"); err == nil {
+		t.Error("was expecting an error for a missing crash")
+	}
+
+	for _, path := range []string{"seed/fuzzer", "fuzzer/fuzzer", "crash-crash"} {
+		if _, err := os.Stat(filepath.Join(dst, path)); err != nil {
+			t.Error(err)
+		}
+	}
+	if crashPath != filepath.Join(dst, "crash-crash") {
+		t.Errorf("unexpected crash path %s", crashPath)
+	}
+}
@@ -0,0 +1,63 @@
+package client
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Engine runs a fuzzing engine in the agent. All methods are called from the job working directory
+// after the fuzzer, corpus, seed and additional corpus were downloaded
+type Engine interface {
+	// Prepare makes sure the fuzzer and any engine tooling are ready to run
+	Prepare(c *FuzzitClient) error
+	// Fuzz runs fuzzing sessions until a crash is found or the job is cancelled, and updates the job status
+	Fuzz(c *FuzzitClient) error
+	// Regress runs the corpus, seed and additional corpus once through the fuzzer and updates the job status
+	Regress(c *FuzzitClient) error
+	// Merge minimizes the corpus and uploads it
+	Merge(c *FuzzitClient) error
+	// ClassifyExit maps an exit code of the fuzzer to a job status: pass, crash, timeout, oom or failed
+	ClassifyExit(exitCode int) string
+	// DefaultHost is the docker image used when the job doesn't specify one
+	DefaultHost() string
+	// RawFuzzer is the filename the fuzzer is stored as, as is, if the engine doesn't use a .tar.gz archive.
+	// Empty for engines using an archive
+	RawFuzzer() string
+}
+
+var engines = map[string]Engine{}
+
+// RegisterEngine makes engine available to jobs under name. It is meant to be called from init()
+func RegisterEngine(name string, engine Engine) {
+	if _, ok := engines[name]; ok {
+		panic(fmt.Sprintf("engine %s is already registered", name))
+	}
+	engines[name] = engine
+}
+
+func GetEngine(name string) (Engine, error) {
+	engine, ok := engines[name]
+	if !ok {
+		return nil, fmt.Errorf("engine should be one of %s. Received: %s", strings.Join(EngineNames(), "/"), name)
+	}
+	return engine, nil
+}
+
+// exitStatus maps an exit code of the fuzzer of the current job to a job status with the engine of the job
+func (c *FuzzitClient) exitStatus(exitCode int) string {
+	engine, err := GetEngine(c.currentJob.Engine)
+	if err != nil {
+		return "failed"
+	}
+	return engine.ClassifyExit(exitCode)
+}
+
+func EngineNames() []string {
+	var names []string
+	for name := range engines {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
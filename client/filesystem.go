@@ -0,0 +1,301 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FileBackend keeps everything in a directory on disk so fuzzit can run without network access.
+// Documents are stored as <root>/<path>.json and blobs as <root>/<storagePath>, using the same
+// orgs/<org>/targets/<target>/... layout as the hosted service
+type FileBackend struct {
+	Root string
+	// issuesMu serializes the read-modify-write of issues
+	issuesMu sync.Mutex
+}
+
+func NewFileBackend(root string) (*FileBackend, error) {
+	if root == "" {
+		return nil, fmt.Errorf("file backend requires a directory")
+	}
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return nil, err
+	}
+
+	return &FileBackend{Root: root}, nil
+}
+
+func (b *FileBackend) Authenticate() (Account, error) {
+	return Account{}, nil
+}
+
+func (b *FileBackend) documentPath(path string) string {
+	return filepath.Join(b.Root, filepath.FromSlash(path)) + ".json"
+}
+
+func (b *FileBackend) readDocument(path string, dst interface{}) error {
+	data, err := ioutil.ReadFile(b.documentPath(path))
+	if os.IsNotExist(err) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, dst)
+}
+
+func (b *FileBackend) writeDocument(path string, doc interface{}) error {
+	docPath := b.documentPath(path)
+	if err := os.MkdirAll(filepath.Dir(docPath), 0755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(doc, "", " ")
+	if err != nil {
+		return err
+	}
+
+	// write to a temporary file first so a concurrent reader never sees a partial document
+	tmpPath := docPath + ".tmp"
+	if err := ioutil.WriteFile(tmpPath, data, 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpPath, docPath)
+}
+
+func (b *FileBackend) updateDocument(path string, fields map[string]interface{}) error {
+	var doc map[string]interface{}
+	if err := b.readDocument(path, &doc); err != nil {
+		return err
+	}
+
+	for k, v := range fields {
+		doc[k] = v
+	}
+
+	return b.writeDocument(path, doc)
+}
+
+func (b *FileBackend) GetDocument(path string) (map[string]interface{}, error) {
+	var doc map[string]interface{}
+	if err := b.readDocument(path, &doc); err != nil {
+		return nil, err
+	}
+
+	return doc, nil
+}
+
+func (b *FileBackend) ListDocuments(path string) ([]map[string]interface{}, error) {
+	files, err := ioutil.ReadDir(filepath.Join(b.Root, filepath.FromSlash(path)))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var docs []map[string]interface{}
+	for _, f := range files {
+		if f.IsDir() || !strings.HasSuffix(f.Name(), ".json") {
+			continue
+		}
+		id := strings.TrimSuffix(f.Name(), ".json")
+		doc, err := b.GetDocument(path + "/" + id)
+		if err != nil {
+			return nil, err
+		}
+		doc["id"] = id
+		docs = append(docs, doc)
+	}
+
+	return docs, nil
+}
+
+func (b *FileBackend) QueryDocuments(path string, query Query) ([]map[string]interface{}, string, error) {
+	docs, err := b.ListDocuments(path)
+	if err != nil {
+		return nil, "", err
+	}
+	return queryDocuments(docs, query)
+}
+
+func (b *FileBackend) DeleteDocument(path string) error {
+	if err := os.Remove(b.documentPath(path)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	// the directory of the sub-collections goes away with its last document
+	os.Remove(filepath.Join(b.Root, filepath.FromSlash(path)))
+	return nil
+}
+
+func (b *FileBackend) GetTarget(org string, targetId string) (*Target, error) {
+	target := Target{}
+	if err := b.readDocument(fmt.Sprintf("orgs/%s/targets/%s", org, targetId), &target); err != nil {
+		return nil, err
+	}
+	return &target, nil
+}
+
+func (b *FileBackend) SetTarget(org string, target Target) error {
+	return b.writeDocument(fmt.Sprintf("orgs/%s/targets/%s", org, target.Name), target)
+}
+
+func (b *FileBackend) NewJobId(org string, targetId string) string {
+	return strings.Replace(uuid.New().String(), "-", "", -1)
+}
+
+func (b *FileBackend) GetJob(org string, targetId string, jobId string) (*Job, error) {
+	job := Job{}
+	if err := b.readDocument(fmt.Sprintf("orgs/%s/targets/%s/jobs/%s", org, targetId, jobId), &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (b *FileBackend) SetJob(org string, jobId string, job Job) error {
+	if _, err := b.GetTarget(org, job.TargetId); err != nil {
+		return err
+	}
+	if job.StartedAt.IsZero() {
+		job.StartedAt = time.Now()
+	}
+	return b.writeDocument(fmt.Sprintf("orgs/%s/targets/%s/jobs/%s", org, job.TargetId, jobId), job)
+}
+
+func (b *FileBackend) UpdateJobStatus(org string, targetId string, jobId string, status string) error {
+	return b.updateDocument(
+		fmt.Sprintf("orgs/%s/targets/%s/jobs/%s", org, targetId, jobId),
+		map[string]interface{}{"status": status})
+}
+
+func (b *FileBackend) NewCrashId(org string, targetId string, jobId string) string {
+	return strings.Replace(uuid.New().String(), "-", "", -1)
+}
+
+func (b *FileBackend) SetCrash(org string, crashId string, crash Crash) error {
+	if crash.Time.IsZero() {
+		crash.Time = time.Now()
+	}
+	return b.writeDocument(fmt.Sprintf("orgs/%s/targets/%s/jobs/%s/crashes/%s", org, crash.TargetId, crash.JobId, crashId), crash)
+}
+
+func (b *FileBackend) AddIssueCrash(org string, targetId string, signature string, issue Issue) (*Issue, error) {
+	b.issuesMu.Lock()
+	defer b.issuesMu.Unlock()
+
+	issuePath := fmt.Sprintf("orgs/%s/targets/%s/issues/%s", org, targetId, signature)
+	now := time.Now()
+	var stored Issue
+	err := b.readDocument(issuePath, &stored)
+	if err == ErrNotFound {
+		stored = issue
+		stored.FirstSeen = now
+		stored.Count = 0
+	} else if err != nil {
+		return nil, err
+	}
+	if stored.Artifact == "" && issue.Artifact != "" {
+		stored.JobId, stored.CrashId = issue.JobId, issue.CrashId
+		stored.Artifact, stored.MinimizedArtifact = issue.Artifact, issue.MinimizedArtifact
+	}
+	stored.Count++
+	stored.LastSeen = now
+	stored.LastJobId = issue.LastJobId
+	stored.LastCrashId = issue.LastCrashId
+
+	if err := b.writeDocument(issuePath, stored); err != nil {
+		return nil, err
+	}
+	return &stored, nil
+}
+
+func (b *FileBackend) ClearIssueArtifact(org string, targetId string, signature string) error {
+	b.issuesMu.Lock()
+	defer b.issuesMu.Unlock()
+
+	issuePath := fmt.Sprintf("orgs/%s/targets/%s/issues/%s", org, targetId, signature)
+	var issue Issue
+	if err := b.readDocument(issuePath, &issue); err != nil {
+		return err
+	}
+	issue.JobId, issue.CrashId, issue.Artifact, issue.MinimizedArtifact = "", "", "", ""
+	return b.writeDocument(issuePath, issue)
+}
+
+// AddJobStats names the samples after their time so they are listed in order
+func (b *FileBackend) AddJobStats(org string, targetId string, jobId string, stats JobStats) error {
+	if stats.Time.IsZero() {
+		stats.Time = time.Now()
+	}
+	return b.writeDocument(fmt.Sprintf("orgs/%s/targets/%s/jobs/%s/stats/%d", org, targetId, jobId, stats.Time.UnixNano()), stats)
+}
+
+func (b *FileBackend) SetJobLogs(org string, targetId string, jobId string, logsId string, logs JobLogs) error {
+	if logs.Time.IsZero() {
+		logs.Time = time.Now()
+	}
+	return b.writeDocument(fmt.Sprintf("orgs/%s/targets/%s/jobs/%s/logs/%s", org, targetId, jobId, logsId), logs)
+}
+
+func (b *FileBackend) blobPath(storagePath string) string {
+	return filepath.Join(b.Root, filepath.FromSlash(storagePath))
+}
+
+func (b *FileBackend) UploadFile(filePath string, storagePath string, filename string) error {
+	dst := b.blobPath(storagePath)
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+
+	if _, err := copyFile(dst+".tmp", filePath); err != nil {
+		return err
+	}
+	if err := os.Rename(dst+".tmp", dst); err != nil {
+		return err
+	}
+
+	// the original filename is kept next to the blob, the same way Content-Disposition is used by the hosted storage
+	return ioutil.WriteFile(dst+".filename", []byte(filename), 0644)
+}
+
+func (b *FileBackend) DownloadFile(filePath string, storagePath string) (string, error) {
+	src := b.blobPath(storagePath)
+	if _, err := os.Stat(src); os.IsNotExist(err) {
+		return "", ErrNotFound
+	}
+
+	if _, err := copyFile(filePath, src); err != nil {
+		return "", err
+	}
+
+	filename, err := ioutil.ReadFile(src + ".filename")
+	if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+
+	return string(filename), nil
+}
+
+func (b *FileBackend) DeleteFile(storagePath string) error {
+	src := b.blobPath(storagePath)
+	for _, path := range []string{src, src + ".filename"} {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package client
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileBackend(t *testing.T) {
+	c, backend, dir, cleanup := newFileClient(t)
+	defer cleanup()
+
+	if err := c.CreateTarget(Target{Name: "parse-complex"}, "", false); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.CreateTarget(Target{Name: "parse-complex"}, "", false); err == nil {
+		t.Errorf("was expecting an error when creating an existing target")
+	}
+	if err := c.CreateTarget(Target{Name: "parse-complex"}, "", true); err != nil {
+		t.Errorf("was expecting no error with skipIfExists received %s", err.Error())
+	}
+
+	jobId, err := c.CreateJob(Job{TargetId: "parse-complex", Engine: "libfuzzer", Type: "fuzzing"}, "", []string{"testdata/fuzzer"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	job, err := backend.GetJob("fuzzitdev", "parse-complex", jobId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Status != "queued" || job.StartedAt.IsZero() {
+		t.Errorf("unexpected job %+v", job)
+	}
+
+	if err := backend.UpdateJobStatus("fuzzitdev", "parse-complex", jobId, "in progress"); err != nil {
+		t.Fatal(err)
+	}
+	docs, err := backend.ListDocuments("orgs/fuzzitdev/targets/parse-complex/jobs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) != 1 || docs[0]["id"] != jobId || docs[0]["status"] != "in progress" {
+		t.Errorf("unexpected jobs %v", docs)
+	}
+
+	if _, err := backend.GetJob("fuzzitdev", "parse-complex", "invalid-job"); err != ErrNotFound {
+		t.Errorf("was expecting %s received %v", ErrNotFound, err)
+	}
+	if err := c.DownloadAndExtractCorpus(dir, "parse-complex"); err == nil || err.Error() != "404 Not Found" {
+		t.Errorf("was expecting 404 Not Found received %v", err)
+	}
+
+	extractDir, err := ioutil.TempDir("", "fuzzit-extract")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(extractDir)
+	if err := c.DownloadAndExtractFuzzer(extractDir, "parse-complex", jobId); err != nil {
+		t.Fatal(err)
+	}
+	if c.fuzzerFilename != "fuzzer.tar.gz" {
+		t.Errorf("was expecting fuzzer.tar.gz received %s", c.fuzzerFilename)
+	}
+	if _, err := os.Stat(filepath.Join(extractDir, "fuzzer")); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCancelJobs(t *testing.T) {
+	c, backend, _, cleanup := newFileClient(t)
+	defer cleanup()
+
+	if err := c.CreateTarget(Target{Name: "parse-complex"}, "", false); err != nil {
+		t.Fatal(err)
+	}
+	for jobId, status := range map[string]string{"queued-job": "queued", "finished-job": "pass"} {
+		if err := backend.SetJob("fuzzitdev", jobId, Job{TargetId: "parse-complex", Status: status}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// a finished job doesn't stop the other jobs from being cancelled
+	if err := c.CancelJobs("parse-complex", []string{"finished-job", "queued-job"}, 0); err == nil {
+		t.Error("was expecting an error when cancelling a finished job")
+	}
+	if err := c.CancelJobs("parse-complex", nil, 0); err != nil {
+		t.Fatal(err)
+	}
+	for jobId, expected := range map[string]string{"queued-job": "cancelled", "finished-job": "pass"} {
+		job, err := backend.GetJob("fuzzitdev", "parse-complex", jobId)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if job.Status != expected {
+			t.Errorf("%s: expected status %s received %s", jobId, expected, job.Status)
+		}
+	}
+}
+
+// newFileClient returns a client of the fuzzitdev org using a file backend in a temporary directory. The
+// returned function removes the directory
+func newFileClient(t *testing.T) (*FuzzitClient, Backend, string, func()) {
+	dir, err := ioutil.TempDir("", "fuzzit")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	backend, err := NewBackend("file://"+dir, "")
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+	c, err := NewFuzzitClientWithBackend("", backend)
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+	c.Org = "fuzzitdev"
+
+	return c, backend, dir, func() { os.RemoveAll(dir) }
+}
@@ -0,0 +1,319 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+const firestoreProjectId = "fuzzit-b5fbf"
+
+// FirestoreBackend is the backend of the hosted fuzzit service. Documents are stored in Firestore
+// and blobs are uploaded/downloaded via signed urls returned by FuzzitEndpoint
+type FirestoreBackend struct {
+	signedURLStorage
+	ApiKey          string
+	firestoreClient *firestore.Client
+	httpClient      *http.Client
+}
+
+func NewFirestoreBackend(apiKey string) (*FirestoreBackend, error) {
+	ctx := context.Background()
+
+	b := &FirestoreBackend{}
+	b.httpClient = &http.Client{Timeout: 120 * time.Second}
+	b.ApiKey = apiKey
+	b.signedURLStorage = signedURLStorage{endpoint: FuzzitEndpoint, apiKey: apiKey}
+
+	conn, err := grpc.Dial("firestore.googleapis.com", grpc.WithInsecure())
+	if err != nil {
+		return nil, err
+	}
+
+	firestoreClient, err := firestore.NewClient(ctx, firestoreProjectId, option.WithGRPCConn(conn))
+	b.firestoreClient = firestoreClient
+	if err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+func (b *FirestoreBackend) GetDocument(path string) (map[string]interface{}, error) {
+	ctx := context.Background()
+
+	docRef := b.firestoreClient.Doc(path)
+	if docRef == nil {
+		return nil, fmt.Errorf("invalid resource %s", path)
+	}
+	docsnap, err := docRef.Get(ctx)
+	if err != nil {
+		if grpc.Code(err) == codes.NotFound {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	if !docsnap.Exists() {
+		return nil, ErrNotFound
+	}
+
+	return docsnap.Data(), nil
+}
+
+func (b *FirestoreBackend) ListDocuments(path string) ([]map[string]interface{}, error) {
+	ctx := context.Background()
+
+	colRef := b.firestoreClient.Collection(path)
+	if colRef == nil {
+		return nil, fmt.Errorf("invalid resource %s", path)
+	}
+	iter := colRef.Documents(ctx)
+	defer iter.Stop()
+
+	var docs []map[string]interface{}
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		data := doc.Data()
+		data["id"] = doc.Ref.ID
+		docs = append(docs, data)
+	}
+
+	return docs, nil
+}
+
+func (b *FirestoreBackend) QueryDocuments(path string, query Query) ([]map[string]interface{}, string, error) {
+	ctx := context.Background()
+
+	colRef := b.firestoreClient.Collection(path)
+	if colRef == nil {
+		return nil, "", fmt.Errorf("invalid resource %s", path)
+	}
+	q := colRef.Query
+	for _, filter := range query.Filters {
+		q = q.Where(filter.Field, "==", filter.typedValue())
+	}
+	if !query.Since.IsZero() {
+		q = q.Where(query.SinceField, ">=", query.Since)
+	}
+	if query.OrderBy != "" {
+		if strings.HasPrefix(query.OrderBy, "-") {
+			q = q.OrderBy(strings.TrimPrefix(query.OrderBy, "-"), firestore.Desc)
+		} else {
+			q = q.OrderBy(query.OrderBy, firestore.Asc)
+		}
+	}
+	if query.PageToken != "" {
+		// the cursor is the snapshot of the last document of the previous page
+		last, err := colRef.Doc(query.PageToken).Get(ctx)
+		if err != nil {
+			if grpc.Code(err) == codes.NotFound {
+				return nil, "", fmt.Errorf("invalid page token %s", query.PageToken)
+			}
+			return nil, "", err
+		}
+		q = q.StartAfter(last)
+	}
+	if query.Limit > 0 {
+		// one more document tells if there is a next page
+		q = q.Limit(query.Limit + 1)
+	}
+
+	iter := q.Documents(ctx)
+	defer iter.Stop()
+
+	var docs []map[string]interface{}
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, "", err
+		}
+		data := doc.Data()
+		data["id"] = doc.Ref.ID
+		docs = append(docs, data)
+	}
+
+	nextPageToken := ""
+	if query.Limit > 0 && len(docs) > query.Limit {
+		docs = docs[:query.Limit]
+		nextPageToken = docs[query.Limit-1]["id"].(string)
+	}
+	return docs, nextPageToken, nil
+}
+
+func (b *FirestoreBackend) DeleteDocument(path string) error {
+	ctx := context.Background()
+
+	_, err := b.firestoreClient.Doc(path).Delete(ctx)
+	return err
+}
+
+func (b *FirestoreBackend) getDocumentTo(path string, dst interface{}) error {
+	ctx := context.Background()
+
+	docRef := b.firestoreClient.Doc(path)
+	if docRef == nil {
+		return fmt.Errorf("invalid resource %s", path)
+	}
+	docsnap, err := docRef.Get(ctx)
+	if err != nil {
+		if grpc.Code(err) == codes.NotFound {
+			return ErrNotFound
+		}
+		return err
+	}
+
+	return docsnap.DataTo(dst)
+}
+
+func (b *FirestoreBackend) GetTarget(org string, targetId string) (*Target, error) {
+	target := Target{}
+	if err := b.getDocumentTo(fmt.Sprintf("orgs/%s/targets/%s", org, targetId), &target); err != nil {
+		return nil, err
+	}
+	return &target, nil
+}
+
+func (b *FirestoreBackend) SetTarget(org string, target Target) error {
+	ctx := context.Background()
+
+	_, err := b.firestoreClient.Doc(fmt.Sprintf("orgs/%s/targets/%s", org, target.Name)).Set(ctx, target)
+	return err
+}
+
+func (b *FirestoreBackend) NewJobId(org string, targetId string) string {
+	return b.firestoreClient.Collection(fmt.Sprintf("orgs/%s/targets/%s/jobs", org, targetId)).NewDoc().ID
+}
+
+func (b *FirestoreBackend) GetJob(org string, targetId string, jobId string) (*Job, error) {
+	job := Job{}
+	if err := b.getDocumentTo(fmt.Sprintf("orgs/%s/targets/%s/jobs/%s", org, targetId, jobId), &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (b *FirestoreBackend) SetJob(org string, jobId string, job Job) error {
+	ctx := context.Background()
+
+	_, err := b.firestoreClient.Doc(fmt.Sprintf("orgs/%s/targets/%s/jobs/%s", org, job.TargetId, jobId)).Set(ctx, job)
+	return err
+}
+
+func (b *FirestoreBackend) UpdateJobStatus(org string, targetId string, jobId string, status string) error {
+	ctx := context.Background()
+
+	// transaction doesnt work for now at go client with oauth token
+	jobRef := b.firestoreClient.Doc(fmt.Sprintf("orgs/%s/targets/%s/jobs/%s", org, targetId, jobId))
+	_, err := jobRef.Update(ctx, []firestore.Update{{Path: "status", Value: status}})
+	return err
+}
+
+func (b *FirestoreBackend) NewCrashId(org string, targetId string, jobId string) string {
+	return b.firestoreClient.Collection(fmt.Sprintf("orgs/%s/targets/%s/jobs/%s/crashes", org, targetId, jobId)).NewDoc().ID
+}
+
+func (b *FirestoreBackend) SetCrash(org string, crashId string, crash Crash) error {
+	ctx := context.Background()
+
+	_, err := b.firestoreClient.Doc(
+		fmt.Sprintf("orgs/%s/targets/%s/jobs/%s/crashes/%s", org, crash.TargetId, crash.JobId, crashId)).Set(ctx, crash)
+	return err
+}
+
+func (b *FirestoreBackend) AddIssueCrash(org string, targetId string, signature string, issue Issue) (*Issue, error) {
+	ctx := context.Background()
+
+	issueRef := b.firestoreClient.Doc(fmt.Sprintf("orgs/%s/targets/%s/issues/%s", org, targetId, signature))
+	issue.Count = 1
+	_, err := issueRef.Create(ctx, issue)
+	if err == nil {
+		return &issue, nil
+	}
+	if grpc.Code(err) != codes.AlreadyExists {
+		return nil, err
+	}
+
+	// transaction doesnt work for now at go client with oauth token so concurrent duplicates can miss a count
+	docsnap, err := issueRef.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var stored Issue
+	if err := docsnap.DataTo(&stored); err != nil {
+		return nil, err
+	}
+	stored.Count++
+	stored.LastSeen = time.Now()
+	stored.LastJobId = issue.LastJobId
+	stored.LastCrashId = issue.LastCrashId
+	updates := []firestore.Update{
+		{Path: "count", Value: stored.Count},
+		{Path: "last_seen", Value: firestore.ServerTimestamp},
+		{Path: "last_job_id", Value: stored.LastJobId},
+		{Path: "last_crash_id", Value: stored.LastCrashId},
+	}
+	if stored.Artifact == "" && issue.Artifact != "" {
+		stored.JobId, stored.CrashId = issue.JobId, issue.CrashId
+		stored.Artifact, stored.MinimizedArtifact = issue.Artifact, issue.MinimizedArtifact
+		updates = append(updates,
+			firestore.Update{Path: "job_id", Value: stored.JobId},
+			firestore.Update{Path: "crash_id", Value: stored.CrashId},
+			firestore.Update{Path: "artifact", Value: stored.Artifact},
+			firestore.Update{Path: "minimized_artifact", Value: stored.MinimizedArtifact},
+		)
+	}
+	_, err = issueRef.Update(ctx, updates)
+	if err != nil {
+		return nil, err
+	}
+	return &stored, nil
+}
+
+func (b *FirestoreBackend) ClearIssueArtifact(org string, targetId string, signature string) error {
+	ctx := context.Background()
+
+	issueRef := b.firestoreClient.Doc(fmt.Sprintf("orgs/%s/targets/%s/issues/%s", org, targetId, signature))
+	_, err := issueRef.Update(ctx, []firestore.Update{
+		{Path: "job_id", Value: ""},
+		{Path: "crash_id", Value: ""},
+		{Path: "artifact", Value: ""},
+		{Path: "minimized_artifact", Value: ""},
+	})
+	if grpc.Code(err) == codes.NotFound {
+		return ErrNotFound
+	}
+	return err
+}
+
+func (b *FirestoreBackend) AddJobStats(org string, targetId string, jobId string, stats JobStats) error {
+	ctx := context.Background()
+
+	_, _, err := b.firestoreClient.Collection(
+		fmt.Sprintf("orgs/%s/targets/%s/jobs/%s/stats", org, targetId, jobId)).Add(ctx, stats)
+	return err
+}
+
+func (b *FirestoreBackend) SetJobLogs(org string, targetId string, jobId string, logsId string, logs JobLogs) error {
+	ctx := context.Background()
+
+	_, err := b.firestoreClient.Doc(
+		fmt.Sprintf("orgs/%s/targets/%s/jobs/%s/logs/%s", org, targetId, jobId, logsId)).Set(ctx, logs)
+	return err
+}
@@ -1,47 +1,59 @@
 package client
 
 import (
+	"archive/zip"
 	"context"
+	"encoding/binary"
+	"encoding/json"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"log"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
 	"strings"
+	"syscall"
 	"time"
 )
 
-func (c *FuzzitClient) uploadGoFuzzCrash(path string) error {
-	ctx := context.Background()
+const (
+	goFuzzRegressionDir = "regression-corpus"
+
+	// layout of the file shared with the binaries of a go-fuzz-build zip: coverage, input and sonar regions
+	goFuzzCoverSize       = 64 << 10
+	goFuzzMaxInputSize    = 1 << 20
+	goFuzzSonarRegionSize = 1 << 20
+	// go-fuzz reports inputs running longer than this (its -timeout default) as hangs
+	goFuzzInputTimeout = 10 * time.Second
+)
 
+func (c *FuzzitClient) uploadGoFuzzCrash(path string) error {
 	if !c.updateDB {
 		return nil
 	}
 
-	colRef := c.firestoreClient.Collection(fmt.Sprintf("orgs/%s/targets/%s/jobs/%s/crashes", c.Org, c.currentJob.TargetId, c.jobId))
-	crashRef := colRef.NewDoc()
+	crashId := c.backend.NewCrashId(c.Org, c.currentJob.TargetId, c.jobId)
 
-	log.Printf("uploading crash...")
-	if err := c.uploadFile(path,
-		fmt.Sprintf("orgs/%s/targets/%s/jobs/%s/crashes/%s", c.Org, c.currentJob.TargetId, c.jobId, crashRef.ID),
-		fmt.Sprintf("crash-%s", crashRef.ID)); err != nil {
-		return err
+	// go-fuzz writes the output of each crasher next to it
+	var report CrashReport
+	if output, err := ioutil.ReadFile(path + ".output"); err == nil {
+		report = ParseCrashReport(strings.Split(string(output), "\n"))
 	}
-
-	_, err := crashRef.Set(ctx, crash{
+	return c.saveCrash(path, fmt.Sprintf("crash-%s", crashId), crashId, Crash{
 		TargetName: c.currentJob.TargetId,
 		JobId:      c.jobId,
 		TargetId:   c.currentJob.TargetId,
 		OrgId:      c.Org,
 		Type:       "crash",
 		V2:         true,
+		Sanitizer:  report.Sanitizer,
+		BugType:    report.BugType,
+		AccessType: report.AccessType,
+		AccessSize: report.AccessSize,
+		Frames:     report.Frames,
 	})
-	if err != nil {
-		return err
-	}
-
-	return nil
 }
 
 func (c *FuzzitClient) loadCurrentCrashes() (map[string]bool, error) {
@@ -68,34 +80,15 @@ func (c *FuzzitClient) loadCurrentCrashes() (map[string]bool, error) {
 }
 
 func (c *FuzzitClient) runGoFuzzFuzzing() error {
-	ctx := context.Background()
-
-	args := append(
-		[]string{
-			"-workdir=workdir",
-			"-procs=1",
-			"-bin=fuzzer.zip",
-		},
-	)
-
-	var err error
-	if runtime.GOOS == "linux" {
-		err = DownloadFile("go-fuzz", "https://storage.googleapis.com/public-fuzzit/go-fuzz-linux")
-	} else if runtime.GOOS == "darwin" {
-		err = DownloadFile("go-fuzz", "https://storage.googleapis.com/public-fuzzit/go-fuzz-osx")
-	} else {
-		return fmt.Errorf("fuzzit with go-fuzz currently only supports linux or darwin")
-	}
-	if err != nil {
-		return err
-	}
-	if err := os.Chmod("./go-fuzz", 0770); err != nil {
-		return err
+	args := []string{
+		"-workdir=workdir",
+		"-procs=1",
+		"-bin=fuzzer.zip",
 	}
 
 	log.Println("downloading previous go-fuzz workdir")
 	workdirPath := fmt.Sprintf("orgs/%s/targets/%s/jobs/%s/workdir.tar.gz", c.Org, c.currentJob.TargetId, c.jobId)
-	err = c.downloadAndExtract(".", workdirPath)
+	err := c.downloadAndExtract(".", workdirPath)
 	if err != nil {
 		if err.Error() == "404 Not Found" {
 			log.Println("no generating corpus yet. continue...")
@@ -151,19 +144,10 @@ func (c *FuzzitClient) runGoFuzzFuzzing() error {
 	for !stopSession {
 		select {
 		case <-timeout:
-			var fuzzingJob Job
 			if err := c.refreshToken(); err != nil {
 				return err
 			}
-			docRef := c.firestoreClient.Doc(fmt.Sprintf("orgs/%s/targets/%s/jobs/%s", c.Org, c.currentJob.TargetId, c.jobId))
-			if docRef == nil {
-				return fmt.Errorf("invalid resource")
-			}
-			docsnap, err := docRef.Get(ctx)
-			if err != nil {
-				return err
-			}
-			err = docsnap.DataTo(&fuzzingJob)
+			fuzzingJob, err := c.backend.GetJob(c.Org, c.currentJob.TargetId, c.jobId)
 			if err != nil {
 				return err
 			}
@@ -172,7 +156,7 @@ func (c *FuzzitClient) runGoFuzzFuzzing() error {
 			} else {
 				log.Println("job was cancel by user. exiting...")
 				cmd.Process.Kill()
-				return nil
+				return c.acknowledgeCancel(fuzzingJob.Status)
 			}
 
 			err = filepath.Walk("workdir/crashers", func(path string, info os.FileInfo, err error) error {
@@ -202,17 +186,7 @@ func (c *FuzzitClient) runGoFuzzFuzzing() error {
 
 			now := time.Now()
 			if now.Sub(lastUpload).Seconds() > 3600 {
-				log.Println("uploading workdir...")
-				if err := c.archiveAndUpload("workdir",
-					fmt.Sprintf("orgs/%s/targets/%s/jobs/%s/workdir.tar.gz", c.Org, c.currentJob.TargetId, c.jobId),
-					"workdir.tar.gz"); err != nil {
-					return err
-				}
-
-				log.Println("uploading corpus...")
-				if err := c.archiveAndUpload("workdir/corpus",
-					fmt.Sprintf("orgs/%s/targets/%s/corpus.tar.gz", c.Org, c.currentJob.TargetId),
-					"corpus.tar.gz"); err != nil {
+				if err := (goFuzzEngine{}).Merge(c); err != nil {
 					return err
 				}
 
@@ -243,14 +217,383 @@ func (c *FuzzitClient) runGoFuzzFuzzing() error {
 
 }
 
-func (c *FuzzitClient) runGoFuzz() error {
-	var err error
+// downloadPreviousCrashes downloads the crashes reported by the jobs of the target to dst so regression
+// runs replay them along with the corpus
+func (c *FuzzitClient) downloadPreviousCrashes(dst string) error {
+	jobs, err := c.backend.ListDocuments(fmt.Sprintf("orgs/%s/targets/%s/jobs", c.Org, c.currentJob.TargetId))
+	if err != nil {
+		return err
+	}
+	for _, job := range jobs {
+		jobId, _ := job["id"].(string)
+		if engine, _ := job["engine"].(string); engine != "go-fuzz" || jobId == "" || jobId == c.jobId {
+			continue
+		}
+		crashes, err := c.backend.ListDocuments(fmt.Sprintf("orgs/%s/targets/%s/jobs/%s/crashes", c.Org, c.currentJob.TargetId, jobId))
+		if err != nil {
+			return err
+		}
+		for _, crash := range crashes {
+			crashId, _ := crash["id"].(string)
+			if crashId == "" {
+				continue
+			}
+			storagePath := fmt.Sprintf("orgs/%s/targets/%s/jobs/%s/crashes/%s", c.Org, c.currentJob.TargetId, jobId, crashId)
+			if _, err := c.backend.DownloadFile(filepath.Join(dst, "crash-"+crashId), storagePath); err != nil {
+				if err == ErrNotFound {
+					continue
+				}
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// goFuzzTestee runs inputs through the cover binary of a go-fuzz-build zip the way go-fuzz does: the input is
+// written to a file shared with the binary, which is told its length over a pipe and replies once the fuzz
+// function returned. The binary is restarted after each crash
+type goFuzzTestee struct {
+	bin    string
+	fnidx  uint8
+	comm   *os.File
+	output *os.File
+
+	cmd       *exec.Cmd
+	toTestee  *os.File
+	replies   *os.File
+	replyDone chan error
+}
+
+// newGoFuzzTestee extracts the cover binary and the fuzz function of the go-fuzz-build zip to dir
+func newGoFuzzTestee(zipPath string, dir string) (*goFuzzTestee, error) {
+	r, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	var metadata struct {
+		Funcs       []string
+		DefaultFunc string
+	}
+	t := &goFuzzTestee{bin: filepath.Join(dir, "cover.exe")}
+	for _, f := range r.File {
+		switch f.Name {
+		case "metadata":
+			rc, err := f.Open()
+			if err != nil {
+				return nil, err
+			}
+			err = json.NewDecoder(rc).Decode(&metadata)
+			rc.Close()
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode go-fuzz-build metadata: %v", err)
+			}
+		case "cover.exe":
+			rc, err := f.Open()
+			if err != nil {
+				return nil, err
+			}
+			bin, err := os.OpenFile(t.bin, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0770)
+			if err != nil {
+				rc.Close()
+				return nil, err
+			}
+			_, err = io.Copy(bin, rc)
+			rc.Close()
+			if closeErr := bin.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	if len(metadata.Funcs) == 0 {
+		return nil, fmt.Errorf("%s has no fuzz functions. please rebuild it with a recent go-fuzz-build", zipPath)
+	}
+
+	fnname := metadata.DefaultFunc
+	if fnname == "" && len(metadata.Funcs) == 1 {
+		fnname = metadata.Funcs[0]
+	}
+	fnidx := -1
+	for i, name := range metadata.Funcs {
+		if name == fnname {
+			fnidx = i
+		}
+	}
+	if fnidx == -1 {
+		return nil, fmt.Errorf("no default fuzz function in %s: %s", zipPath, strings.Join(metadata.Funcs, ", "))
+	}
+	t.fnidx = uint8(fnidx)
+
+	if t.comm, err = os.Create(filepath.Join(dir, "comm")); err != nil {
+		return nil, err
+	}
+	if err := t.comm.Truncate(goFuzzCoverSize + goFuzzMaxInputSize + goFuzzSonarRegionSize); err != nil {
+		t.close()
+		return nil, err
+	}
+	// the output is truncated before each input so only the output of the crashing input is kept
+	if t.output, err = os.OpenFile(filepath.Join(dir, "output"), os.O_CREATE|os.O_TRUNC|os.O_RDWR|os.O_APPEND, 0644); err != nil {
+		t.close()
+		return nil, err
+	}
+	return t, nil
+}
+
+func (t *goFuzzTestee) start() error {
+	fromAgent, toTestee, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	replies, fromTestee, err := os.Pipe()
+	if err != nil {
+		fromAgent.Close()
+		toTestee.Close()
+		return err
+	}
+	defer fromAgent.Close()
+	defer fromTestee.Close()
+
+	cmd := exec.Command(t.bin)
+	cmd.Env = append(os.Environ(), "GOTRACEBACK=1")
+	cmd.Stdout = t.output
+	cmd.Stderr = t.output
+	// the binary expects the shared file as fd 3, reads the inputs from fd 4 and replies on fd 5
+	cmd.ExtraFiles = []*os.File{t.comm, fromAgent, fromTestee}
+	if err := cmd.Start(); err != nil {
+		toTestee.Close()
+		replies.Close()
+		return err
+	}
+	t.cmd, t.toTestee, t.replies = cmd, toTestee, replies
+	return nil
+}
 
-	if c.currentJob.Type == "fuzzing" {
-		err = c.runGoFuzzFuzzing()
+// stop kills the binary and returns its exit code
+func (t *goFuzzTestee) stop() (int, error) {
+	t.cmd.Process.Kill()
+	exitCode, err := exitCodeFromError(t.cmd.Wait())
+	t.toTestee.Close()
+	t.replies.Close()
+	t.cmd = nil
+	return exitCode, err
+}
+
+// run executes input and returns whether it crashed the binary, with the exit code and output of the
+// crash. An input running longer than goFuzzInputTimeout returns context.DeadlineExceeded
+func (t *goFuzzTestee) run(input []byte) (crashed bool, exitCode int, output []byte, err error) {
+	if len(input) > goFuzzMaxInputSize {
+		input = input[:goFuzzMaxInputSize]
+	}
+	if t.cmd == nil {
+		if err := t.start(); err != nil {
+			return false, 0, nil, err
+		}
+	}
+	if err := t.output.Truncate(0); err != nil {
+		return false, 0, nil, err
+	}
+	if _, err := t.comm.WriteAt(input, goFuzzCoverSize); err != nil {
+		return false, 0, nil, err
+	}
+
+	request := make([]byte, 9)
+	request[0] = t.fnidx
+	binary.LittleEndian.PutUint64(request[1:], uint64(len(input)))
+	replied := make(chan error, 1)
+	go func() {
+		if _, err := t.toTestee.Write(request); err != nil {
+			replied <- err
+			return
+		}
+		// result, duration and sonar size of the input
+		_, err := io.ReadFull(t.replies, make([]byte, 24))
+		replied <- err
+	}()
+
+	hanged := false
+	select {
+	case err := <-replied:
+		if err == nil {
+			return false, 0, nil, nil
+		}
+	case <-time.After(goFuzzInputTimeout):
+		hanged = true
+		// like go-fuzz, abort the binary first so its output has the goroutines of the hang
+		t.cmd.Process.Signal(syscall.SIGABRT)
+		select {
+		case <-replied:
+		case <-time.After(time.Second):
+		}
+	}
+
+	// the binary exited or hanged on the input
+	exitCode, err = t.stop()
+	if err != nil {
+		return false, 0, nil, err
+	}
+	if output, err = ioutil.ReadFile(t.output.Name()); err != nil {
+		return false, 0, nil, err
+	}
+	if hanged {
+		return true, exitCode, output, context.DeadlineExceeded
+	}
+	return true, exitCode, output, nil
+}
+
+func (t *goFuzzTestee) close() {
+	if t.cmd != nil {
+		t.stop()
+	}
+	if t.comm != nil {
+		t.comm.Close()
+	}
+	if t.output != nil {
+		t.output.Close()
+	}
+}
+
+// runGoFuzzRegression runs every corpus, seed and previous crasher input once through the go-fuzz-build zip
+// and fails on the first one crashing
+func (c *FuzzitClient) runGoFuzzRegression() error {
+	inputs, err := c.copyInputFiles(goFuzzRegressionDir)
+	if err != nil {
+		return err
+	}
+
+	// a reproduced crash is the only input
+	if c.crashId == "" {
+		log.Println("downloading previous crashers")
+		if err := c.downloadPreviousCrashes(goFuzzRegressionDir); err != nil {
+			log.Printf("could not download previous crashers: %v. continue...", err)
+		}
+	}
+	regressionFiles, err := listFiles(goFuzzRegressionDir)
+	if err != nil {
+		return err
+	}
+	if len(regressionFiles) == 0 {
+		log.Println("no files in corpus and seed. skipping run")
+		c.transitionStatus("pass")
+		return nil
+	}
+
+	testeeDir, err := ioutil.TempDir("", "go-fuzz-regression")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(testeeDir)
+	testee, err := newGoFuzzTestee("fuzzer.zip", testeeDir)
+	if err != nil {
+		c.transitionStatus("failed")
+		return err
+	}
+	defer testee.close()
+
+	log.Printf("Running regression on %d inputs (%d previous crashers)...", len(regressionFiles), len(regressionFiles)-inputs)
+	for _, regressionFile := range regressionFiles {
+		input, err := ioutil.ReadFile(regressionFile)
+		if err != nil {
+			return err
+		}
+		status := "crash"
+		crashed, exitCode, output, err := testee.run(input)
+		if err == context.DeadlineExceeded {
+			status = "timeout"
+			output = append([]byte(fmt.Sprintf("program hanged (timeout %s)\n\n", goFuzzInputTimeout)), output...)
+		} else if err != nil {
+			return err
+		} else if !crashed {
+			continue
+		}
+
+		setLastLines(strings.Split(string(output), "\n"))
+		log.Printf("%s failed with exit code %d", regressionFile, exitCode)
+		if !c.updateDB {
+			// if this is local regression we want to exit with error code so the ci can fail
+			return fmt.Errorf("regression failed on %s with exit code %d\n%s", regressionFile, exitCode, string(output))
+		}
+		if err := c.refreshToken(); err != nil {
+			return err
+		}
+		if err := c.uploadCrashFile(regressionFile, exitCode, getLastLines()); err != nil {
+			return err
+		}
+		return c.transitionStatus(status)
+	}
+
+	return c.transitionStatus("pass")
+}
+
+type goFuzzEngine struct{}
+
+func init() {
+	RegisterEngine("go-fuzz", goFuzzEngine{})
+}
+
+func (goFuzzEngine) Prepare(c *FuzzitClient) error {
+	// local regression jobs receive the zip in the fuzzer archive as fuzzer
+	if _, err := os.Stat("fuzzer.zip"); os.IsNotExist(err) {
+		if err := os.Rename("fuzzer", "fuzzer.zip"); err != nil {
+			c.transitionStatus("failed")
+			return fmt.Errorf("go-fuzz-build zip doesnt exist")
+		}
+	}
+
+	var err error
+	if runtime.GOOS == "linux" {
+		err = DownloadFile("go-fuzz", "https://storage.googleapis.com/public-fuzzit/go-fuzz-linux")
+	} else if runtime.GOOS == "darwin" {
+		err = DownloadFile("go-fuzz", "https://storage.googleapis.com/public-fuzzit/go-fuzz-osx")
 	} else {
-		return fmt.Errorf("go-fuzz currently only supports fuzzing")
+		return fmt.Errorf("fuzzit with go-fuzz currently only supports linux or darwin")
+	}
+	if err != nil {
+		return err
+	}
+
+	return os.Chmod("./go-fuzz", 0770)
+}
+
+func (goFuzzEngine) Fuzz(c *FuzzitClient) error {
+	return c.runGoFuzzFuzzing()
+}
+
+func (goFuzzEngine) Regress(c *FuzzitClient) error {
+	return c.runGoFuzzRegression()
+}
+
+// Merge uploads the go-fuzz workdir for the next run of the job and its corpus as the target corpus.
+// go-fuzz minimizes the corpus by itself while fuzzing
+func (goFuzzEngine) Merge(c *FuzzitClient) error {
+	log.Println("uploading workdir...")
+	if err := c.archiveAndUpload("workdir",
+		fmt.Sprintf("orgs/%s/targets/%s/jobs/%s/workdir.tar.gz", c.Org, c.currentJob.TargetId, c.jobId),
+		"workdir.tar.gz"); err != nil {
+		return err
 	}
 
-	return err
+	log.Println("uploading corpus...")
+	return c.archiveAndUpload("workdir/corpus",
+		fmt.Sprintf("orgs/%s/targets/%s/corpus.tar.gz", c.Org, c.currentJob.TargetId),
+		"corpus.tar.gz")
+}
+
+func (goFuzzEngine) ClassifyExit(exitCode int) string {
+	if exitCode == 0 {
+		return "pass"
+	}
+	return "failed"
+}
+
+func (goFuzzEngine) DefaultHost() string {
+	return "gcr.io/fuzzit-public/stretch-llvm8:64bdedf"
+}
+
+func (goFuzzEngine) RawFuzzer() string {
+	return "fuzzer.zip"
 }
@@ -0,0 +1,82 @@
+package client
+
+import (
+	"archive/zip"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeGoFuzzZip writes a go-fuzz-build zip of testdata/gofuzz with the metadata to dir
+func writeGoFuzzZip(t *testing.T, dir string, metadata string) string {
+	bin := filepath.Join(dir, "gofuzz")
+	if output, err := exec.Command("go", "build", "-o", bin, "./testdata/gofuzz").CombinedOutput(); err != nil {
+		t.Fatalf("%v: %s", err, output)
+	}
+	data, err := ioutil.ReadFile(bin)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	zipPath := filepath.Join(dir, "fuzzer.zip")
+	f, err := os.Create(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	w := zip.NewWriter(f)
+	for name, content := range map[string][]byte{"cover.exe": data, "metadata": []byte(metadata)} {
+		entry, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := entry.Write(content); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return zipPath
+}
+
+func TestGoFuzzTestee(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go is required to build the testee")
+	}
+	dir, err := ioutil.TempDir("", "fuzzit-gofuzz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if _, err := newGoFuzzTestee(writeGoFuzzZip(t, dir, `{"Funcs": ["FuzzA", "FuzzB"]}`), dir); err == nil {
+		t.Error("was expecting an error without a default fuzz function")
+	}
+
+	testee, err := newGoFuzzTestee(writeGoFuzzZip(t, dir, `{"Funcs": ["Fuzz"]}`), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testee.close()
+
+	// the binary is restarted after the crash and only the crashing input has output
+	for _, input := range []string{"first", "crash", "second", "crash"} {
+		crashed, exitCode, output, err := testee.run([]byte(input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if input != "crash" {
+			if crashed {
+				t.Errorf("%s: unexpected crash %s", input, output)
+			}
+			continue
+		}
+		if !crashed || exitCode == 0 || !strings.HasPrefix(string(output), "panic: crash input") {
+			t.Errorf("%s: was expecting a crash received %v %d %s", input, crashed, exitCode, output)
+		}
+	}
+}
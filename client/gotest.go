@@ -0,0 +1,385 @@
+package client
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	goTestCacheDir  = "fuzzcache"
+	goTestSeedDir   = "testdata/fuzz"
+	goTestCorpusHdr = "go test fuzz v1\n"
+)
+
+func goTestExitCodeToStatus(exitCode int) string {
+	status := "pass"
+	switch exitCode {
+	case goTestSuccessExitCode:
+		status = "pass"
+	case goTestFailExitCode:
+		status = "crash"
+	case goTestPanicExitCode:
+		status = "crash"
+	default:
+		status = "failed"
+	}
+
+	return status
+}
+
+// goTestCrashStatus refines the status of a failing fuzz test with its output
+func goTestCrashStatus(output []string) string {
+	for _, line := range output {
+		if strings.Contains(line, "runtime: out of memory") {
+			return "oom"
+		}
+		if strings.Contains(line, "fuzzing process hung") {
+			return "timeout"
+		}
+	}
+	return "crash"
+}
+
+// goTestFuzzTarget returns the fuzz test passed in the job args as -test.fuzz=FuzzX and the rest of the args
+func (c *FuzzitClient) goTestFuzzTarget() (string, []string, error) {
+	var fuzzTarget string
+	var args []string
+	for _, arg := range splitAndRemoveEmpty(c.currentJob.Args, " ") {
+		if strings.HasPrefix(arg, "-test.fuzz=") {
+			fuzzTarget = strings.Trim(strings.TrimPrefix(arg, "-test.fuzz="), "^$")
+		} else {
+			args = append(args, arg)
+		}
+	}
+	if fuzzTarget == "" {
+		return "", nil, fmt.Errorf("gotest engine requires the fuzz test in the job args e.g --args -test.fuzz=FuzzParse")
+	}
+	return fuzzTarget, args, nil
+}
+
+// encodeGoTestCorpusFile converts raw inputs (e.g seed corpus shared with other engines) to the go fuzzing
+// corpus format of a fuzz test taking a single []byte. files already in this format are kept as is
+func encodeGoTestCorpusFile(data []byte) []byte {
+	if bytes.HasPrefix(data, []byte(goTestCorpusHdr)) {
+		return data
+	}
+	return []byte(fmt.Sprintf("%s[]byte(%s)\n", goTestCorpusHdr, strconv.Quote(string(data))))
+}
+
+// copyGoTestCorpus copies inputFiles to dst in the go fuzzing corpus format, named like go names them
+func copyGoTestCorpus(dst string, inputFiles []string) error {
+	if err := os.MkdirAll(dst, 0755); err != nil {
+		return err
+	}
+	for _, inputFile := range inputFiles {
+		data, err := ioutil.ReadFile(inputFile)
+		if err != nil {
+			return err
+		}
+		data = encodeGoTestCorpusFile(data)
+		name := fmt.Sprintf("%x", sha256.Sum256(data))[:16]
+		if err := ioutil.WriteFile(filepath.Join(dst, name), data, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func listDirNames(dir string) (map[string]bool, error) {
+	names := map[string]bool{}
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return names, nil
+		}
+		return nil, err
+	}
+	for _, file := range files {
+		if !file.IsDir() {
+			names[file.Name()] = true
+		}
+	}
+	return names, nil
+}
+
+func (c *FuzzitClient) runGoTestFuzzing() error {
+	fuzzTarget, extraArgs, err := c.goTestFuzzTarget()
+	if err != nil {
+		return err
+	}
+	seedDir := filepath.Join(goTestSeedDir, fuzzTarget)
+
+	args := append([]string{
+		fmt.Sprintf("-test.run=^%s$", fuzzTarget),
+		fmt.Sprintf("-test.fuzz=^%s$", fuzzTarget),
+		fmt.Sprintf("-test.fuzztime=%ds", fuzzingInterval),
+		"-test.fuzzcachedir=" + goTestCacheDir,
+	}, extraArgs...)
+
+	for {
+		// go writes failing inputs to testdata/fuzz/FuzzX/<hash>. anything new after a session is a crash
+		seeds, err := listDirNames(seedDir)
+		if err != nil {
+			return err
+		}
+
+		log.Println("Running fuzzing with: ./fuzzer " + strings.Join(args, " "))
+		cmd := exec.Command("./fuzzer", args...)
+		if err := appendPrefixToCmd(cmd); err != nil {
+			return err
+		}
+
+		exitCode, cancelled, err := c.runFuzzerSession(cmd, nil)
+		if err != nil {
+			return err
+		}
+		if cancelled {
+			return nil
+		}
+
+		if exitCode == goTestSuccessExitCode {
+			if err := (goTestEngine{}).Merge(c); err != nil {
+				return err
+			}
+			log.Print("process finished successfully")
+			continue
+		}
+
+		status := c.exitStatus(exitCode)
+		crashers, err := listDirNames(seedDir)
+		if err != nil {
+			return err
+		}
+		var found []string
+		for crasher := range crashers {
+			if !seeds[crasher] {
+				found = append(found, crasher)
+			}
+		}
+		output := getLastLines()
+		for _, crasher := range found {
+			log.Printf("go test found crash %s", crasher)
+			status = goTestCrashStatus(output)
+			if err := c.refreshToken(); err != nil {
+				return err
+			}
+			// the output can only be told apart when a single crasher was found
+			crasherOutput := output
+			if len(found) > 1 {
+				crasherOutput = nil
+			}
+			if err := c.uploadCrashFile(filepath.Join(seedDir, crasher), exitCode, crasherOutput); err != nil {
+				return err
+			}
+		}
+
+		return c.transitionStatus(status)
+	}
+}
+
+// runGoTestRegression runs the fuzz test without -test.fuzz, which executes every file in testdata/fuzz/FuzzX.
+// The corpus is copied there first so it is replayed as well
+func (c *FuzzitClient) runGoTestRegression() error {
+	fuzzTarget, extraArgs, err := c.goTestFuzzTarget()
+	if err != nil {
+		return err
+	}
+	seedDir := filepath.Join(goTestSeedDir, fuzzTarget)
+
+	corpusFiles, err := listFiles("corpus")
+	if err != nil {
+		return err
+	}
+	if err := copyGoTestCorpus(seedDir, corpusFiles); err != nil {
+		return err
+	}
+
+	args := append([]string{
+		fmt.Sprintf("-test.run=^%s$", fuzzTarget),
+		"-test.v",
+	}, extraArgs...)
+
+	log.Println("Running regression...")
+	cmd := exec.Command("./fuzzer", args...)
+	if err := appendPrefixToCmd(cmd); err != nil {
+		return err
+	}
+
+	runErr := cmd.Run()
+	exitCode, err := exitCodeFromError(runErr)
+	if err != nil {
+		return err
+	}
+	if exitCode != 0 && !c.updateDB {
+		// if this is local regression we want to exit with error code so the ci can fail
+		return runErr
+	}
+
+	status := c.exitStatus(exitCode)
+	if status == "crash" {
+		output := getLastLines()
+		status = goTestCrashStatus(output)
+		// the first failing input is uploaded and the first report of the output is its own
+		crashFile := goTestFailingInput(seedDir, fuzzTarget, output)
+		if crashFile != "" {
+			if err := c.uploadCrashFile(crashFile, exitCode, output); err != nil {
+				return err
+			}
+		}
+	}
+
+	return c.transitionStatus(status)
+}
+
+// goTestFailingInput finds the corpus file that failed in the -test.v output. failures are reported as
+// --- FAIL: FuzzX/<name>, while a panic stops the run right after === RUN   FuzzX/<name>. seeds added with
+// f.Add don't have a file and aren't returned
+func goTestFailingInput(seedDir string, fuzzTarget string, output []string) string {
+	failPrefix := fmt.Sprintf("--- FAIL: %s/", fuzzTarget)
+	runPrefix := fmt.Sprintf("=== RUN   %s/", fuzzTarget)
+	name := ""
+	for _, line := range output {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, failPrefix) {
+			name = strings.Fields(strings.TrimPrefix(line, failPrefix))[0]
+			break
+		}
+		if strings.HasPrefix(line, runPrefix) {
+			name = strings.TrimPrefix(line, runPrefix)
+		}
+	}
+	if name == "" {
+		return ""
+	}
+
+	crashFile := filepath.Join(seedDir, name)
+	if _, err := os.Stat(crashFile); err != nil {
+		return ""
+	}
+	return crashFile
+}
+
+type goTestEngine struct{}
+
+func init() {
+	RegisterEngine("gotest", goTestEngine{})
+}
+
+// Prepare lays out the inputs the way go test expects them: the corpus in the fuzz cache and the seed
+// and additional corpus in testdata/fuzz/FuzzX, next to the seeds shipped in the fuzzer archive
+func (goTestEngine) Prepare(c *FuzzitClient) error {
+	if _, err := os.Stat("fuzzer"); os.IsNotExist(err) {
+		c.transitionStatus("failed")
+		return fmt.Errorf("fuzzer executable doesnt exist")
+	}
+	fuzzTarget, _, err := c.goTestFuzzTarget()
+	if err != nil {
+		c.transitionStatus("failed")
+		return err
+	}
+
+	corpusFiles, err := listFiles("corpus")
+	if err != nil {
+		return err
+	}
+	if err := copyGoTestCorpus(filepath.Join(goTestCacheDir, fuzzTarget), corpusFiles); err != nil {
+		return err
+	}
+
+	var seedFiles []string
+	for _, dir := range []string{"seed", "additional-corpus"} {
+		files, err := listFiles(dir)
+		if err != nil {
+			return err
+		}
+		seedFiles = append(seedFiles, files...)
+	}
+	if err := copyGoTestCorpus(filepath.Join(goTestSeedDir, fuzzTarget), seedFiles); err != nil {
+		return err
+	}
+
+	return os.Chmod("./fuzzer", 0770)
+}
+
+func (goTestEngine) Fuzz(c *FuzzitClient) error {
+	return c.runGoTestFuzzing()
+}
+
+func (goTestEngine) Regress(c *FuzzitClient) error {
+	return c.runGoTestRegression()
+}
+
+// Merge uploads the fuzz cache as the corpus. go test has no corpus minimization so the cache is taken as is
+func (goTestEngine) Merge(c *FuzzitClient) error {
+	fuzzTarget, _, err := c.goTestFuzzTarget()
+	if err != nil {
+		return err
+	}
+	cacheDir := filepath.Join(goTestCacheDir, fuzzTarget)
+
+	isEmpty, err := IsDirEmpty(cacheDir)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err != nil || isEmpty {
+		log.Println("nothing to merge. skipping...")
+		return nil
+	}
+
+	if err := os.RemoveAll("merge"); err != nil {
+		return err
+	}
+	if err := os.Mkdir("merge", 0644); err != nil {
+		return err
+	}
+	cacheFiles, err := listDirNames(cacheDir)
+	if err != nil {
+		return err
+	}
+	for name := range cacheFiles {
+		if _, err := copyFile(filepath.Join("merge", name), filepath.Join(cacheDir, name)); err != nil {
+			return err
+		}
+	}
+
+	if err := c.refreshToken(); err != nil {
+		return err
+	}
+
+	if err := os.RemoveAll("corpus"); err != nil {
+		return err
+	}
+
+	if err := os.Rename("merge", "corpus"); err != nil {
+		return err
+	}
+
+	if err := c.archiveAndUpload("corpus",
+		fmt.Sprintf("orgs/%s/targets/%s/corpus.tar.gz", c.Org, c.currentJob.TargetId),
+		"corpus.tar.gz"); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (goTestEngine) ClassifyExit(exitCode int) string {
+	return goTestExitCodeToStatus(exitCode)
+}
+
+// the fuzzer is a test binary built with go test -c so the go toolchain isn't needed in the image
+func (goTestEngine) DefaultHost() string {
+	return "gcr.io/fuzzit-public/stretch-llvm8:64bdedf"
+}
+
+func (goTestEngine) RawFuzzer() string {
+	return ""
+}
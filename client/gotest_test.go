@@ -0,0 +1,58 @@
+package client
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEncodeGoTestCorpusFile(t *testing.T) {
+	encoded := string(encodeGoTestCorpusFile([]byte("boom\x00")))
+	expected := "go test fuzz v1\n[]byte(\"boom\\x00\")\n"
+	if encoded != expected {
+		t.Errorf("expected %q received %q", expected, encoded)
+	}
+	if string(encodeGoTestCorpusFile([]byte(expected))) != expected {
+		t.Errorf("corpus files should be kept as is")
+	}
+}
+
+func TestGoTestFailingInput(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fuzzit-gotest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for _, name := range []string{"b3160b13fbc4c81e", "def63e61adfe51cc"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(goTestCorpusHdr), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	failed := []string{
+		"=== RUN   FuzzParse",
+		"=== RUN   FuzzParse/b3160b13fbc4c81e",
+		"=== RUN   FuzzParse/def63e61adfe51cc",
+		"    --- FAIL: FuzzParse/b3160b13fbc4c81e (0.00s)",
+		"--- FAIL: FuzzParse (0.00s)",
+	}
+	if crashFile := goTestFailingInput(dir, "FuzzParse", failed); crashFile != filepath.Join(dir, "b3160b13fbc4c81e") {
+		t.Errorf("expected the failing entry received %s", crashFile)
+	}
+
+	panicked := []string{
+		"panic: boom [recovered]",
+		"=== RUN   FuzzParse",
+		"=== RUN   FuzzParse/b3160b13fbc4c81e",
+		"=== RUN   FuzzParse/def63e61adfe51cc",
+	}
+	if crashFile := goTestFailingInput(dir, "FuzzParse", panicked); crashFile != filepath.Join(dir, "def63e61adfe51cc") {
+		t.Errorf("expected the last entry that ran received %s", crashFile)
+	}
+
+	seed := []string{"=== RUN   FuzzParse/seed#0", "--- FAIL: FuzzParse/seed#0 (0.00s)"}
+	if crashFile := goTestFailingInput(dir, "FuzzParse", seed); crashFile != "" {
+		t.Errorf("expected no file for f.Add seeds received %s", crashFile)
+	}
+}
@@ -0,0 +1,381 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	honggfuzzInputDir     = "hfuzz-in"
+	honggfuzzWorkspaceDir = "hfuzz-workspace"
+	// non persistent fuzzers take the input file name in place of this argument, or the input on stdin without it
+	honggfuzzFilePlaceholder = "___FILE___"
+	// fuzzers built for persistent mode are asked to run in it with this job arg
+	honggfuzzPersistentArg = "--persistent"
+	// honggfuzz describes the crashes it saves in this file of the workspace and makes the sanitizers of the
+	// fuzzer log their reports to files with this prefix next to it
+	honggfuzzReportFile         = "HONGGFUZZ.REPORT.TXT"
+	honggfuzzSanitizerLogPrefix = "HF.sanitizer.log"
+
+	honggfuzzRegressionTimeout = 30 * time.Second
+)
+
+func honggfuzzExitCodeToStatus(exitCode int) string {
+	if exitCode == honggfuzzSuccessExitCode {
+		return "pass"
+	}
+	return "failed"
+}
+
+// honggfuzzCrashStatus maps a honggfuzz crash file (e.g SIGSEGV.PC.4f7a1b.STACK.1a2b3c.CODE.1.ADDR.0.INSTR.mov.fuzz)
+// to a job status. timeouts are saved as SIGVTALRM crashes because the fuzzer runs with --tmout_sigvtalrm
+func honggfuzzCrashStatus(crashFile string) string {
+	if strings.HasPrefix(crashFile, "SIGVTALRM.") {
+		return "timeout"
+	}
+	return "crash"
+}
+
+// honggfuzzSignals are the signals, with their linux numbers, honggfuzz names its crash files after
+var honggfuzzSignals = map[string]int{
+	"SIGILL":    4,
+	"SIGABRT":   6,
+	"SIGBUS":    7,
+	"SIGFPE":    8,
+	"SIGSEGV":   11,
+	"SIGVTALRM": 26,
+}
+
+// honggfuzzCrashExitCode returns the exit code of the run which saved a crash file, 128+signal like a shell does
+func honggfuzzCrashExitCode(crashFile string) int {
+	if signal, ok := honggfuzzSignals[strings.SplitN(crashFile, ".", 2)[0]]; ok {
+		return 128 + signal
+	}
+	return 1
+}
+
+// honggfuzzCrashReport returns the sanitizer report of a crash file. honggfuzz records the pid of the run
+// which saved each crash file in its report file and makes the sanitizers log to HF.sanitizer.log.<pid>.
+// It returns nil if there is no report e.g the fuzzer isn't built with a sanitizer
+func honggfuzzCrashReport(workspace string, crashFile string) ([]string, error) {
+	data, err := ioutil.ReadFile(filepath.Join(workspace, honggfuzzReportFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	pid := ""
+	isCrashFile := false
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.HasPrefix(line, "FUZZ_FNAME: ") {
+			isCrashFile = filepath.Base(strings.TrimSpace(strings.TrimPrefix(line, "FUZZ_FNAME: "))) == crashFile
+		} else if strings.HasPrefix(line, "PID: ") && isCrashFile {
+			pid = strings.TrimSpace(strings.TrimPrefix(line, "PID: "))
+		}
+	}
+	if pid == "" {
+		return nil, nil
+	}
+
+	report, err := ioutil.ReadFile(filepath.Join(workspace, honggfuzzSanitizerLogPrefix+"."+pid))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return strings.Split(string(report), "\n"), nil
+}
+
+// sanitizerStatus refines the status of a crashing run with the sanitizer report in its output
+func sanitizerStatus(output []string, status string) string {
+	for _, line := range output {
+		if strings.Contains(line, "ERROR: ") && (strings.Contains(line, "out-of-memory") || strings.Contains(line, "out of memory")) {
+			return "oom"
+		}
+	}
+	return status
+}
+
+// honggfuzzBinary prefers a honggfuzz shipped in the fuzzer archive over the one installed in the host image
+func honggfuzzBinary() string {
+	if _, err := os.Stat("honggfuzz"); err == nil {
+		return "./honggfuzz"
+	}
+	return "honggfuzz"
+}
+
+// honggfuzzArgs returns the fuzzer command line and whether it runs in persistent mode, which is asked for
+// with --persistent in the job args
+func (c *FuzzitClient) honggfuzzArgs() (args []string, persistent bool) {
+	args = []string{"./fuzzer"}
+	for _, arg := range splitAndRemoveEmpty(c.currentJob.Args, " ") {
+		if arg == honggfuzzPersistentArg {
+			persistent = true
+			continue
+		}
+		args = append(args, arg)
+	}
+	return args, persistent
+}
+
+// honggfuzzModeArgs returns the honggfuzz flags telling how the fuzzer takes its inputs
+func honggfuzzModeArgs(fuzzerArgs []string, persistent bool) []string {
+	if persistent {
+		return []string{"--persistent"}
+	}
+	if !Contains(fuzzerArgs, honggfuzzFilePlaceholder) {
+		return []string{"--stdin_input"}
+	}
+	return nil
+}
+
+// uploadHonggfuzzCrashes uploads the crashes found in the workspace that weren't uploaded yet. uploaded maps
+// each crash file to its status
+func (c *FuzzitClient) uploadHonggfuzzCrashes(uploaded map[string]string) error {
+	files, err := ioutil.ReadDir(honggfuzzWorkspaceDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), ".fuzz") {
+			continue
+		}
+		if _, ok := uploaded[file.Name()]; ok {
+			continue
+		}
+		log.Printf("honggfuzz found crash %s", file.Name())
+		if err := c.refreshToken(); err != nil {
+			return err
+		}
+		// the output of honggfuzz mixes the reports of all the crashes, each one is in its own sanitizer log
+		report, err := honggfuzzCrashReport(honggfuzzWorkspaceDir, file.Name())
+		if err != nil {
+			return err
+		}
+		if err := c.uploadCrashFile(filepath.Join(honggfuzzWorkspaceDir, file.Name()), honggfuzzCrashExitCode(file.Name()), report); err != nil {
+			return err
+		}
+		uploaded[file.Name()] = sanitizerStatus(report, honggfuzzCrashStatus(file.Name()))
+	}
+
+	return nil
+}
+
+func (c *FuzzitClient) runHonggfuzzFuzzing() error {
+	uploaded := map[string]string{}
+	for {
+		if _, err := c.copyInputFiles(honggfuzzInputDir); err != nil {
+			return err
+		}
+
+		fuzzerArgs, persistent := c.honggfuzzArgs()
+		args := []string{
+			"--input", honggfuzzInputDir,
+			"--workspace", honggfuzzWorkspaceDir,
+			"--run_time", fmt.Sprintf("%d", fuzzingInterval),
+			"--tmout_sigvtalrm",
+		}
+		args = append(args, honggfuzzModeArgs(fuzzerArgs, persistent)...)
+		args = append(args, "--")
+		args = append(args, fuzzerArgs...)
+
+		log.Println("Running fuzzing with: honggfuzz " + strings.Join(args, " "))
+		cmd := exec.Command(honggfuzzBinary(), args...)
+		if err := appendPrefixToCmd(cmd); err != nil {
+			return err
+		}
+
+		exitCode, cancelled, err := c.runFuzzerSession(cmd, func() error {
+			return c.uploadHonggfuzzCrashes(uploaded)
+		})
+		if err != nil {
+			return err
+		}
+		if cancelled {
+			return nil
+		}
+
+		if err := c.uploadHonggfuzzCrashes(uploaded); err != nil {
+			return err
+		}
+		if exitCode != honggfuzzSuccessExitCode {
+			return c.transitionStatus(c.exitStatus(exitCode))
+		}
+
+		if err := (honggfuzzEngine{}).Merge(c); err != nil {
+			return err
+		}
+		log.Print("process finished successfully")
+
+		if len(uploaded) > 0 {
+			// crashes take precedence over ooms which take precedence over timeouts
+			status := "timeout"
+			for _, crashStatus := range uploaded {
+				if crashStatus == "crash" || (crashStatus == "oom" && status == "timeout") {
+					status = crashStatus
+				}
+			}
+			return c.transitionStatus(status)
+		}
+	}
+}
+
+// runHonggfuzzRegression runs every input through the fuzzer directly. fuzzers linked with libhfuzz read a
+// single input from stdin when they don't run under honggfuzz
+func (c *FuzzitClient) runHonggfuzzRegression() error {
+	regressionFiles, err := c.inputFiles()
+	if err != nil {
+		return err
+	}
+	if len(regressionFiles) == 0 {
+		log.Println("no files in corpus and seed. skipping run")
+		c.transitionStatus("pass")
+		return nil
+	}
+
+	fuzzerArgs, _ := c.honggfuzzArgs()
+
+	log.Println("Running regression...")
+	for _, regressionFile := range regressionFiles {
+		setLastLines(nil)
+		status := "crash"
+		exitCode, err := c.runInput(fuzzerArgs, honggfuzzFilePlaceholder, regressionFile, honggfuzzRegressionTimeout)
+		if err == context.DeadlineExceeded {
+			status = "timeout"
+		} else if err != nil {
+			return err
+		} else if exitCode == 0 {
+			continue
+		}
+		output := getLastLines()
+		status = sanitizerStatus(output, status)
+
+		log.Printf("%s failed with exit code %d (%s)", regressionFile, exitCode, status)
+		if !c.updateDB {
+			// if this is local regression we want to exit with error code so the ci can fail
+			return fmt.Errorf("regression failed on %s with exit code %d", regressionFile, exitCode)
+		}
+		if err := c.uploadCrashFile(regressionFile, exitCode, output); err != nil {
+			return err
+		}
+		return c.transitionStatus(status)
+	}
+
+	return c.transitionStatus("pass")
+}
+
+type honggfuzzEngine struct{}
+
+func init() {
+	RegisterEngine("honggfuzz", honggfuzzEngine{})
+}
+
+func (honggfuzzEngine) Prepare(c *FuzzitClient) error {
+	if _, err := os.Stat("fuzzer"); os.IsNotExist(err) {
+		c.transitionStatus("failed")
+		return fmt.Errorf("fuzzer executable doesnt exist")
+	}
+	if c.currentJob.Type != "regression" {
+		if _, err := exec.LookPath(honggfuzzBinary()); err != nil {
+			c.transitionStatus("failed")
+			return fmt.Errorf("honggfuzz is not installed in the host image nor included in the fuzzer archive")
+		}
+	}
+	return os.Chmod("./fuzzer", 0770)
+}
+
+func (honggfuzzEngine) Fuzz(c *FuzzitClient) error {
+	return c.runHonggfuzzFuzzing()
+}
+
+func (honggfuzzEngine) Regress(c *FuzzitClient) error {
+	return c.runHonggfuzzRegression()
+}
+
+// Merge minimizes the inputs of the last session, including the new ones honggfuzz saved there, into the corpus
+func (honggfuzzEngine) Merge(c *FuzzitClient) error {
+	isEmpty, err := IsDirEmpty(honggfuzzInputDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			log.Println("nothing to merge. skipping...")
+			return nil
+		}
+		return err
+	}
+	if isEmpty {
+		log.Println("nothing to merge. skipping...")
+		return nil
+	}
+
+	if err := os.RemoveAll("merge"); err != nil {
+		return err
+	}
+	if err := os.Mkdir("merge", 0644); err != nil {
+		return err
+	}
+
+	fuzzerArgs, persistent := c.honggfuzzArgs()
+	args := []string{
+		"--input", honggfuzzInputDir,
+		"--output", "merge",
+		"--workspace", honggfuzzWorkspaceDir,
+		"--minimize",
+	}
+	args = append(args, honggfuzzModeArgs(fuzzerArgs, persistent)...)
+	args = append(args, "--")
+	args = append(args, fuzzerArgs...)
+
+	log.Println("Running merge with: honggfuzz " + strings.Join(args, " "))
+	cmd := exec.Command(honggfuzzBinary(), args...)
+	if err := appendPrefixToCmd(cmd); err != nil {
+		return err
+	}
+	if err := cmd.Run(); err != nil {
+		return err
+	}
+
+	if err := c.refreshToken(); err != nil {
+		return err
+	}
+
+	if err := os.RemoveAll("corpus"); err != nil {
+		return err
+	}
+
+	if err := os.Rename("merge", "corpus"); err != nil {
+		return err
+	}
+
+	if err := c.archiveAndUpload("corpus",
+		fmt.Sprintf("orgs/%s/targets/%s/corpus.tar.gz", c.Org, c.currentJob.TargetId),
+		"corpus.tar.gz"); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (honggfuzzEngine) ClassifyExit(exitCode int) string {
+	return honggfuzzExitCodeToStatus(exitCode)
+}
+
+// honggfuzz isn't part of the default image. include the honggfuzz binary in the fuzzer archive or use an image with it installed
+func (honggfuzzEngine) DefaultHost() string {
+	return "gcr.io/fuzzit-public/stretch-llvm8:64bdedf"
+}
+
+func (honggfuzzEngine) RawFuzzer() string {
+	return ""
+}
@@ -0,0 +1,66 @@
+package client
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestHonggfuzzCrashStatus(t *testing.T) {
+	tests := []struct {
+		crashFile string
+		output    []string
+		status    string
+	}{
+		{"SIGSEGV.PC.4f7a1b.STACK.1a2b3c.CODE.1.ADDR.0.INSTR.mov.fuzz", nil, "crash"},
+		{"SIGVTALRM.PC.4f7a1b.STACK.1a2b3c.CODE.-6.ADDR.0.INSTR.mov.fuzz", nil, "timeout"},
+		{"SIGABRT.PC.7ffff7a42428.STACK.badbad.CODE.-6.ADDR.0.INSTR.mov.fuzz",
+			[]string{"==1==ERROR: AddressSanitizer: out of memory: allocator is trying to allocate 0x10000000000 bytes"}, "oom"},
+		{"SIGABRT.PC.7ffff7a42428.STACK.badbad.CODE.-6.ADDR.0.INSTR.mov.fuzz",
+			[]string{"==1==ERROR: AddressSanitizer: heap-buffer-overflow on address 0x602000000011"}, "crash"},
+	}
+
+	for _, test := range tests {
+		status := sanitizerStatus(test.output, honggfuzzCrashStatus(test.crashFile))
+		if status != test.status {
+			t.Errorf("%s: expected %s received %s", test.crashFile, test.status, status)
+		}
+	}
+}
+
+func TestHonggfuzzCrashReport(t *testing.T) {
+	workspace, err := ioutil.TempDir("", "fuzzit-honggfuzz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(workspace)
+
+	crashFile := "SIGABRT.PC.7ffff7a42428.STACK.badbad.CODE.-6.ADDR.0.INSTR.mov.fuzz"
+	files := map[string]string{
+		honggfuzzReportFile: "FUZZ_FNAME: hfuzz-workspace/SIGSEGV.PC.4f7a1b.STACK.1a2b3c.CODE.1.ADDR.0.INSTR.mov.fuzz\nPID: 11\n" +
+			"FUZZ_FNAME: hfuzz-workspace/" + crashFile + "\nPID: 12\n",
+		honggfuzzSanitizerLogPrefix + ".11": "==11==ERROR: AddressSanitizer: SEGV on unknown address 0x000000000000\n",
+		honggfuzzSanitizerLogPrefix + ".12": "==12==ERROR: AddressSanitizer: out of memory: allocator is trying to allocate 0x10000000000 bytes\n",
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(workspace, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	report, err := honggfuzzCrashReport(workspace, crashFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status := sanitizerStatus(report, honggfuzzCrashStatus(crashFile)); status != "oom" {
+		t.Errorf("expected oom received %s from %q", status, report)
+	}
+	if exitCode := honggfuzzCrashExitCode(crashFile); exitCode != 134 {
+		t.Errorf("expected 134 received %d", exitCode)
+	}
+
+	if report, err := honggfuzzCrashReport(workspace, "SIGBUS.PC.0.fuzz"); err != nil || report != nil {
+		t.Errorf("was expecting no report received %q %v", report, err)
+	}
+}
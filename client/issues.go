@@ -0,0 +1,90 @@
+package client
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"regexp"
+)
+
+// frameLocationRegexp matches the location at the end of a frame e.g " /src/parse.c:12:3",
+// " Parser.java:12" or " (/lib/x86_64-linux-gnu/libc.so.6+0x21b96)"
+var frameLocationRegexp = regexp.MustCompile(`\s+(?:\S+:\d+(?::\d+)?|\(\S+\+0x[0-9a-f]+\))$`)
+
+// crashSignature identifies the crashes of the same bug across jobs from the bug type and the functions
+// of the top frames. Locations are left out so the signature survives unrelated changes to the code.
+// It's empty if the crash report couldn't be parsed, such crashes aren't grouped
+func crashSignature(bugType string, frames []string) string {
+	if bugType == "" || len(frames) == 0 {
+		return ""
+	}
+
+	h := sha1.New()
+	io.WriteString(h, bugType)
+	for _, frame := range frames {
+		io.WriteString(h, "\n"+frameLocationRegexp.ReplaceAllString(frame, ""))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// saveCrash creates the document of a crash of the current job. The crashing input at path is only
+// uploaded (as filename), along with its minimized version, if its issue doesn't have an input yet
+func (c *FuzzitClient) saveCrash(path string, filename string, crashId string, crash Crash) error {
+	artifact := fmt.Sprintf("orgs/%s/targets/%s/jobs/%s/crashes/%s", c.Org, c.currentJob.TargetId, c.jobId, crashId)
+	crash.Signature = crashSignature(crash.BugType, crash.Frames)
+	crash.Artifact = artifact
+
+	if crash.Signature != "" {
+		issue, err := c.backend.GetDocument(fmt.Sprintf("orgs/%s/targets/%s/issues/%s", c.Org, c.currentJob.TargetId, crash.Signature))
+		if err != nil && err != ErrNotFound {
+			return err
+		}
+		if existing, _ := issue["artifact"].(string); existing != "" {
+			log.Printf("crash is a duplicate of issue %s, skipping upload", crash.Signature)
+			crash.Artifact = existing
+			crash.MinimizedArtifact, _ = issue["minimized_artifact"].(string)
+		}
+	}
+
+	// the input is uploaded before an issue points to it so a failed upload doesn't lose it for the
+	// next crashes of the issue
+	if crash.Artifact == artifact {
+		log.Printf("uploading crash...")
+		if err := c.uploadFile(path, artifact, filename); err != nil {
+			return err
+		}
+
+		if c.currentJob.MinimizeRuns > 0 {
+			minimized, err := c.minimizeCrash(path, artifact+"-minimized", filename+"-minimized")
+			if err != nil {
+				return err
+			}
+			if minimized {
+				crash.MinimizedArtifact = artifact + "-minimized"
+			}
+		}
+	}
+
+	if crash.Signature != "" {
+		issue := Issue{
+			Sanitizer:   crash.Sanitizer,
+			BugType:     crash.BugType,
+			Frames:      crash.Frames,
+			LastJobId:   c.jobId,
+			LastCrashId: crashId,
+		}
+		if crash.Artifact == artifact {
+			issue.JobId = c.jobId
+			issue.CrashId = crashId
+			issue.Artifact = artifact
+			issue.MinimizedArtifact = crash.MinimizedArtifact
+		}
+		if _, err := c.backend.AddIssueCrash(c.Org, c.currentJob.TargetId, crash.Signature, issue); err != nil {
+			return err
+		}
+	}
+
+	return c.backend.SetCrash(c.Org, crashId, crash)
+}
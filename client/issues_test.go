@@ -0,0 +1,113 @@
+package client
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCrashIssues(t *testing.T) {
+	c, backend, dir, cleanup := newFileClient(t)
+	defer cleanup()
+	c.updateDB = true
+	c.currentJob = Job{TargetId: "parse-complex"}
+
+	// the same bug found by two jobs of different revisions
+	crashes := []struct {
+		jobId  string
+		frames []string
+	}{
+		{"job1", []string{"parse /src/parse.c:12:3", "LLVMFuzzerTestOneInput /src/fuzz.c:7:5"}},
+		{"job2", []string{"parse /src/parse.c:14:3", "LLVMFuzzerTestOneInput /src/fuzz.c:7:5"}},
+	}
+	signature := crashSignature("heap-buffer-overflow", crashes[0].frames)
+	issuePath := "orgs/fuzzitdev/targets/parse-complex/issues/" + signature
+
+	// a failed upload doesn't leave an issue pointing to a missing input
+	c.jobId = "job0"
+	err := c.saveCrash(filepath.Join(dir, "missing"), "crash-parse-complex", "crash", Crash{
+		JobId:    "job0",
+		TargetId: "parse-complex",
+		BugType:  "heap-buffer-overflow",
+		Frames:   crashes[0].frames,
+	})
+	if err == nil {
+		t.Error("was expecting an error for a missing input")
+	}
+	if _, err := backend.GetDocument(issuePath); err != ErrNotFound {
+		t.Errorf("was expecting no issue received %v", err)
+	}
+
+	for i, crash := range crashes {
+		c.jobId = crash.jobId
+		err := c.saveCrash("testdata/fuzzer.tar.gz", "crash-parse-complex", fmt.Sprintf("crash%d", i), Crash{
+			JobId:    crash.jobId,
+			TargetId: "parse-complex",
+			BugType:  "heap-buffer-overflow",
+			Frames:   crash.frames,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	issue, err := backend.GetDocument(issuePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if issue["count"] != float64(2) || issue["crash_id"] != "crash0" || issue["last_crash_id"] != "crash1" {
+		t.Errorf("unexpected issue %v", issue)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "orgs/fuzzitdev/targets/parse-complex/jobs/job2/crashes/crash1")); !os.IsNotExist(err) {
+		t.Errorf("the input of a duplicate crash shouldn't be uploaded: %v", err)
+	}
+	crashPath, err := c.DownloadCrash(filepath.Join(dir, "download"), "parse-complex", "job2", "crash1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(crashPath); err != nil {
+		t.Error(err)
+	}
+
+	// deleting the input of the issue makes the next crash upload its input again
+	if err := c.DeleteCrash("parse-complex", "job1", "crash0", false); err != nil {
+		t.Fatal(err)
+	}
+	if issue, err := backend.GetDocument(issuePath); err != nil || issue["artifact"] != "" {
+		t.Errorf("was expecting the issue to forget its input received %v %v", issue, err)
+	}
+	c.jobId = "job2"
+	err = c.saveCrash("testdata/fuzzer.tar.gz", "crash-parse-complex", "crash2", Crash{
+		JobId:    "job2",
+		TargetId: "parse-complex",
+		BugType:  "heap-buffer-overflow",
+		Frames:   crashes[1].frames,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if issue, err := backend.GetDocument(issuePath); err != nil || issue["crash_id"] != "crash2" {
+		t.Errorf("was expecting the issue to point to crash2 received %v %v", issue, err)
+	}
+
+	// the output of the fuzzer may hold the report of another crash, such crashes aren't grouped
+	setLastLines([]string{
+		"==17==ERROR: AddressSanitizer: heap-buffer-overflow on address 0x602000000011 at pc 0x4f8e2a bp 0x7ffc sp 0x7ffc",
+		"    #0 0x4f8e2a in parse /src/parse.c:12:3",
+		"    #1 0x4f8f10 in LLVMFuzzerTestOneInput /src/fuzz.c:7:5",
+	})
+	defer setLastLines(nil)
+	c.jobId = "job3"
+	if err := c.uploadCrashFile("testdata/fuzzer.tar.gz", 1, nil); err != nil {
+		t.Fatal(err)
+	}
+	docs, err := backend.ListDocuments("orgs/fuzzitdev/targets/parse-complex/jobs/job3/crashes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) != 1 || docs[0]["signature"] != "" || docs[0]["bug_type"] != "" {
+		t.Errorf("unexpected crashes %v", docs)
+	}
+}
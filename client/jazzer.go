@@ -0,0 +1,124 @@
+package client
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/mholt/archiver"
+)
+
+const jazzerVersion = "v0.16.1"
+
+// jazzerBinary prefers a jazzer driver shipped in the fuzzer archive over the one installed in the host image
+// or downloaded by Prepare
+func jazzerBinary() string {
+	if _, err := os.Stat("jazzer"); err == nil {
+		return "./jazzer"
+	}
+	return "jazzer"
+}
+
+// jazzerCommand returns the jazzer command line. --cp, --target_class and the other jazzer flags are passed
+// in the job args along with the libFuzzer flags, they're moved to the command line so every run of the
+// fuzzer, merge included, gets them. Flags are given either as --flag=value or as --flag value. The
+// classpath defaults to the fuzzer jar
+func (c *FuzzitClient) jazzerCommand() []string {
+	fuzzer := []string{jazzerBinary()}
+	args := splitAndRemoveEmpty(c.currentJob.Args, " ")
+	var flags []string
+	hasClasspath := false
+	for i := 0; i < len(args); i++ {
+		if !strings.HasPrefix(args[i], "--") {
+			continue
+		}
+		if args[i] == "--cp" || strings.HasPrefix(args[i], "--cp=") {
+			hasClasspath = true
+		}
+		flags = append(flags, args[i])
+		if !strings.Contains(args[i], "=") && i+1 < len(args) && !strings.HasPrefix(args[i+1], "-") {
+			i++
+			flags = append(flags, args[i])
+		}
+	}
+	if !hasClasspath {
+		fuzzer = append(fuzzer, "--cp=fuzzer:fuzzer.jar")
+	}
+	return append(fuzzer, flags...)
+}
+
+func downloadJazzer() error {
+	var url string
+	if runtime.GOOS == "linux" {
+		url = fmt.Sprintf("https://github.com/CodeIntelligenceTesting/jazzer/releases/download/%s/jazzer-linux.tar.gz", jazzerVersion)
+	} else if runtime.GOOS == "darwin" {
+		url = fmt.Sprintf("https://github.com/CodeIntelligenceTesting/jazzer/releases/download/%s/jazzer-macos.tar.gz", jazzerVersion)
+	} else {
+		return fmt.Errorf("fuzzit with jazzer currently only supports linux or darwin")
+	}
+
+	log.Printf("downloading jazzer %s...", jazzerVersion)
+	if err := DownloadFile("jazzer.tar.gz", url); err != nil {
+		return err
+	}
+	if err := archiver.NewTarGz().Unarchive("jazzer.tar.gz", "."); err != nil {
+		return err
+	}
+	return os.Chmod("./jazzer", 0770)
+}
+
+type jazzerEngine struct{}
+
+func init() {
+	RegisterEngine("jazzer", jazzerEngine{})
+}
+
+func (jazzerEngine) Prepare(c *FuzzitClient) error {
+	if _, err := exec.LookPath("java"); err != nil {
+		c.transitionStatus("failed")
+		return fmt.Errorf("java must be installed in the docker to run jazzer fuzzer")
+	}
+	if _, err := os.Stat("fuzzer"); os.IsNotExist(err) {
+		if _, err := os.Stat("fuzzer.jar"); os.IsNotExist(err) {
+			c.transitionStatus("failed")
+			return fmt.Errorf("fuzzer jar doesnt exist")
+		}
+	}
+
+	if _, err := exec.LookPath(jazzerBinary()); err == nil {
+		return nil
+	}
+	return downloadJazzer()
+}
+
+// jazzer is a libFuzzer driver so the libFuzzer flow is reused with the jazzer command line
+func (jazzerEngine) Fuzz(c *FuzzitClient) error {
+	return c.runLibFuzzerFuzzing(c.jazzerCommand())
+}
+
+func (jazzerEngine) Regress(c *FuzzitClient) error {
+	return c.runLibFuzzerRegression(c.jazzerCommand())
+}
+
+func (jazzerEngine) Merge(c *FuzzitClient) error {
+	return c.runlibFuzzerMerge(c.jazzerCommand())
+}
+
+func (jazzerEngine) MinimizeCrash(c *FuzzitClient, path string, dst string, runs int) error {
+	return c.minimizeLibFuzzerCrash(c.jazzerCommand(), path, dst, runs)
+}
+
+func (jazzerEngine) ClassifyExit(exitCode int) string {
+	return libFuzzerExitCodeToStatus(exitCode)
+}
+
+func (jazzerEngine) DefaultHost() string {
+	return "openjdk:11"
+}
+
+func (jazzerEngine) RawFuzzer() string {
+	return ""
+}
@@ -0,0 +1,38 @@
+package client
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestJazzerCommand(t *testing.T) {
+	c := &FuzzitClient{currentJob: Job{Args: "--cp=target.jar --target_class=com.example.ParserFuzzer -max_len=64"}}
+
+	fuzzer := c.jazzerCommand()
+	expected := []string{"jazzer", "--cp=target.jar", "--target_class=com.example.ParserFuzzer"}
+	if !reflect.DeepEqual(fuzzer, expected) {
+		t.Errorf("expected %v received %v", expected, fuzzer)
+	}
+	if args := c.libFuzzerJobArgs(fuzzer); !reflect.DeepEqual(args, []string{"-max_len=64"}) {
+		t.Errorf("expected only the libFuzzer flags received %v", args)
+	}
+}
+
+func TestJazzerCommandSeparateValues(t *testing.T) {
+	c := &FuzzitClient{currentJob: Job{Args: "--cp target.jar -max_len=64 --target_class com.example.ParserFuzzer --keep_going=2"}}
+
+	fuzzer := c.jazzerCommand()
+	expected := []string{"jazzer", "--cp", "target.jar", "--target_class", "com.example.ParserFuzzer", "--keep_going=2"}
+	if !reflect.DeepEqual(fuzzer, expected) {
+		t.Errorf("expected %v received %v", expected, fuzzer)
+	}
+	if args := c.libFuzzerJobArgs(fuzzer); !reflect.DeepEqual(args, []string{"-max_len=64"}) {
+		t.Errorf("expected only the libFuzzer flags received %v", args)
+	}
+
+	c.currentJob.Args = "--target_class com.example.ParserFuzzer"
+	expected = []string{"jazzer", "--cp=fuzzer:fuzzer.jar", "--target_class", "com.example.ParserFuzzer"}
+	if fuzzer := c.jazzerCommand(); !reflect.DeepEqual(fuzzer, expected) {
+		t.Errorf("expected %v received %v", expected, fuzzer)
+	}
+}
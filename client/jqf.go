@@ -5,10 +5,19 @@ import (
 	"fmt"
 	"log"
 	"os/exec"
+	"path/filepath"
+	"strings"
 	"syscall"
 	"time"
 )
 
+const (
+	jqfRegressionDir = "jqf-regression"
+	jqfReproDriver   = "edu.berkeley.cs.jqf.fuzz.repro.ReproDriver"
+	// the repro driver takes the input to replay in place of this argument
+	jqfInputPlaceholder = "___INPUT___"
+)
+
 func jqfExitCodeToStatus(exitCode int) string {
 	status := "pass"
 	switch exitCode {
@@ -24,11 +33,6 @@ func jqfExitCodeToStatus(exitCode int) string {
 }
 
 func (c *FuzzitClient) runJQFFuzzing() error {
-	ctx := context.Background()
-
-	log.Println("downloading zest cli...")
-	err := DownloadFile("zest-cli.jar", "https://storage.googleapis.com/public-fuzzit/jqf-fuzz-1.3-SNAPSHOT-zest-cli.jar")
-
 	args := []string{
 		"-jar",
 		"zest-cli.jar",
@@ -64,19 +68,10 @@ func (c *FuzzitClient) runJQFFuzzing() error {
 		for !stopSession {
 			select {
 			case <-timeout:
-				var fuzzingJob Job
 				if err := c.refreshToken(); err != nil {
 					return err
 				}
-				docRef := c.firestoreClient.Doc(fmt.Sprintf("orgs/%s/targets/%s/jobs/%s", c.Org, c.currentJob.TargetId, c.jobId))
-				if docRef == nil {
-					return fmt.Errorf("invalid resource")
-				}
-				docsnap, err := docRef.Get(ctx)
-				if err != nil {
-					return err
-				}
-				err = docsnap.DataTo(&fuzzingJob)
+				fuzzingJob, err := c.backend.GetJob(c.Org, c.currentJob.TargetId, c.jobId)
 				if err != nil {
 					return err
 				}
@@ -85,7 +80,7 @@ func (c *FuzzitClient) runJQFFuzzing() error {
 				} else {
 					log.Println("job was cancel by user. exiting...")
 					cmd.Process.Kill()
-					return nil
+					return c.acknowledgeCancel(fuzzingJob.Status)
 				}
 			case err = <-done:
 				stopSession = true
@@ -106,7 +101,7 @@ func (c *FuzzitClient) runJQFFuzzing() error {
 						return err
 					}
 				} else {
-					if err = c.runlibFuzzerMerge(); err != nil {
+					if err = (jqfEngine{}).Merge(c); err != nil {
 						return err
 					}
 					log.Print("process finished successfully")
@@ -118,12 +113,12 @@ func (c *FuzzitClient) runJQFFuzzing() error {
 	if err := c.refreshToken(); err != nil {
 		return err
 	}
-	err = c.uploadCrash(exitCode)
+	err = c.uploadCrash(exitCode, getLastLines())
 	if err != nil {
 		return err
 	}
 
-	err = c.transitionStatus(jqfExitCodeToStatus(exitCode))
+	err = c.transitionStatus(c.exitStatus(exitCode))
 	if err != nil {
 		return err
 	}
@@ -131,14 +126,163 @@ func (c *FuzzitClient) runJQFFuzzing() error {
 	return nil
 }
 
-func (c *FuzzitClient) RunJQF() error {
-	var err error
+// jqfOptionsWithValue are the zest-cli options followed by a value, unless it's passed as --option=value
+var jqfOptionsWithValue = map[string]bool{
+	"-i": true, "--input": true,
+	"-o": true, "--output": true,
+	"-d": true, "--duration": true,
+	"--exact-crash-path": true,
+}
+
+// jqfTestMethod returns the test class and method from the job args, skipping zest-cli options and their values
+func (c *FuzzitClient) jqfTestMethod() ([]string, error) {
+	var testMethod []string
+	skipValue := false
+	for _, arg := range splitAndRemoveEmpty(c.currentJob.Args, " ") {
+		if skipValue {
+			skipValue = false
+			continue
+		}
+		if strings.HasPrefix(arg, "-") {
+			skipValue = jqfOptionsWithValue[arg]
+			continue
+		}
+		testMethod = append(testMethod, arg)
+	}
+	if len(testMethod) != 2 {
+		return nil, fmt.Errorf("JQF regression requires the test class and method in the job args. Received: %s", c.currentJob.Args)
+	}
+	return testMethod, nil
+}
+
+// jqfFailingInput finds the input reported as failing by the repro driver e.g 3-id_000012 ::= FAILURE (java.lang.AssertionError)
+func jqfFailingInput(output []string) string {
+	for _, line := range output {
+		split := strings.SplitN(line, " ::= ", 2)
+		if len(split) == 2 && strings.HasPrefix(split[1], "FAILURE") {
+			return strings.TrimSpace(split[0])
+		}
+	}
+	return ""
+}
+
+// runJQFRegression replays the corpus, seed and additional corpus with the JQF repro driver which exits with
+// jqfCrashExitCode if any of them fails
+func (c *FuzzitClient) runJQFRegression() error {
+	testMethod, err := c.jqfTestMethod()
+	if err != nil {
+		c.transitionStatus("failed")
+		return err
+	}
+
+	inputs, err := c.copyInputFiles(jqfRegressionDir)
+	if err != nil {
+		return err
+	}
+	if inputs == 0 {
+		log.Println("no files in corpus and seed. skipping run")
+		c.transitionStatus("pass")
+		return nil
+	}
+
+	args := append([]string{
+		"-cp",
+		// the fuzzer is downloaded as fuzzer.jar when it was uploaded as a jar
+		"zest-cli.jar:fuzzer:fuzzer.jar",
+		jqfReproDriver,
+	}, testMethod...)
+	args = append(args, jqfRegressionDir)
+
+	log.Println("Running regression...")
+	cmd := exec.Command("java", args...)
+	if err := appendPrefixToCmd(cmd); err != nil {
+		return err
+	}
+
+	runErr := cmd.Run()
+	exitCode, err := exitCodeFromError(runErr)
+	if err != nil {
+		return err
+	}
+	if exitCode != jqfSuccessExitCode && !c.updateDB {
+		// if this is local regression we want to exit with error code so the ci can fail
+		return runErr
+	}
+
+	if failingInput := jqfFailingInput(getLastLines()); failingInput != "" {
+		if _, err := copyFile("artifact", filepath.Join(jqfRegressionDir, failingInput)); err != nil {
+			return err
+		}
+	}
+	// the repro driver replays all the inputs so the output can't be told apart
+	if err := c.uploadCrash(exitCode, nil); err != nil {
+		return err
+	}
+
+	return c.transitionStatus(c.exitStatus(exitCode))
+}
+
+type jqfEngine struct{}
+
+func init() {
+	RegisterEngine("jqf", jqfEngine{})
+}
+
+func (jqfEngine) Prepare(c *FuzzitClient) error {
+	if _, err := exec.LookPath("java"); err != nil {
+		c.transitionStatus("failed")
+		return fmt.Errorf("java must be installed in the docker to run JQF fuzzer")
+	}
+
+	log.Println("downloading zest cli...")
+	return DownloadFile("zest-cli.jar", "https://storage.googleapis.com/public-fuzzit/jqf-fuzz-1.3-SNAPSHOT-zest-cli.jar")
+}
+
+func (jqfEngine) Fuzz(c *FuzzitClient) error {
+	return c.runJQFFuzzing()
+}
+
+func (jqfEngine) Regress(c *FuzzitClient) error {
+	return c.runJQFRegression()
+}
 
-	if c.currentJob.Type == "fuzzing" {
-		err = c.runJQFFuzzing()
-	} else {
-		return fmt.Errorf("JQF currently only supports fuzzing")
+func (jqfEngine) Merge(c *FuzzitClient) error {
+	log.Println("JQF doesn't support corpus merge. skipping...")
+	return nil
+}
+
+// MinimizeCrash removes chunks of the input as long as the repro driver still fails on it, JQF has no minimizer
+func (jqfEngine) MinimizeCrash(c *FuzzitClient, path string, dst string, runs int) error {
+	testMethod, err := c.jqfTestMethod()
+	if err != nil {
+		return err
 	}
 
-	return err
+	args := append([]string{"java", "-cp", "zest-cli.jar:fuzzer:fuzzer.jar", jqfReproDriver}, testMethod...)
+	args = append(args, jqfInputPlaceholder)
+	deadline := time.Now().Add(minimizeTimeout)
+	return minimizeInputFile(path, dst, runs, func(candidate string) (bool, error) {
+		if time.Now().After(deadline) {
+			return false, fmt.Errorf("minimization timed out after %s", minimizeTimeout)
+		}
+		exitCode, err := c.runInput(args, jqfInputPlaceholder, candidate, minimizeRunTimeout)
+		if err == context.DeadlineExceeded {
+			return false, nil
+		} else if err != nil {
+			return false, err
+		}
+		return exitCode == jqfCrashExitCode, nil
+	})
+}
+
+func (jqfEngine) ClassifyExit(exitCode int) string {
+	return jqfExitCodeToStatus(exitCode)
+}
+
+func (jqfEngine) DefaultHost() string {
+	return "openjdk:stretch"
+}
+
+func (jqfEngine) RawFuzzer() string {
+	return ""
 }
@@ -0,0 +1,49 @@
+package client
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestJQFTestMethod(t *testing.T) {
+	testCases := []struct {
+		args     string
+		expected []string
+	}{
+		{"com.example.ParserTest fuzzParse", []string{"com.example.ParserTest", "fuzzParse"}},
+		{"--duration 10m com.example.ParserTest fuzzParse -b", []string{"com.example.ParserTest", "fuzzParse"}},
+		{"com.example.ParserTest fuzzParse -d 10m --exact-crash-path crash --input seeds", []string{"com.example.ParserTest", "fuzzParse"}},
+		{"--duration=10m com.example.ParserTest -l fuzzParse", []string{"com.example.ParserTest", "fuzzParse"}},
+		{"com.example.ParserTest", nil},
+		{"com.example.ParserTest fuzzParse extra", nil},
+	}
+	for _, tc := range testCases {
+		c := &FuzzitClient{currentJob: Job{Args: tc.args}}
+		testMethod, err := c.jqfTestMethod()
+		if tc.expected == nil {
+			if err == nil {
+				t.Errorf("%s: was expecting an error received %v", tc.args, testMethod)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tc.args, err)
+		} else if !reflect.DeepEqual(testMethod, tc.expected) {
+			t.Errorf("%s: expected %v received %v", tc.args, tc.expected, testMethod)
+		}
+	}
+}
+
+func TestJQFFailingInput(t *testing.T) {
+	output := []string{
+		"0-id_000001 ::= SUCCESS",
+		"3-id_000012 ::= FAILURE (java.lang.AssertionError)",
+		"4-id_000013 ::= FAILURE (java.lang.NullPointerException)",
+	}
+	if input := jqfFailingInput(output); input != "3-id_000012" {
+		t.Errorf("expected 3-id_000012 received %q", input)
+	}
+	if input := jqfFailingInput(output[:1]); input != "" {
+		t.Errorf("was expecting no failing input received %q", input)
+	}
+}
@@ -3,14 +3,111 @@ package client
 import (
 	"context"
 	"fmt"
+	"io/ioutil"
 	"log"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 )
 
+// parallel workers write their crashes here, named crash-, leak-, timeout- or oom-<sha1>
+const (
+	libFuzzerArtifactsDir = "artifacts"
+	// replaying a crashing input shouldn't take long, it's given up on after this long
+	libFuzzerReplayTimeout = time.Minute
+)
+
+var (
+	// #1048576	pulse  cov: 23 ft: 24 corp: 4/16b lim: 4096 exec/s: 349525 rss: 29Mb
+	libFuzzerStatusRegexp = regexp.MustCompile(`^#(\d+)\s+\w+\s+cov: (\d+) ft: (\d+) corp: (\d+)/\S+ .*exec/s: (\d+) rss: (\d+)Mb`)
+	// #2048: cov: 23 ft: 24 corp: 4 exec/s 1024 oom/timeout/crash: 0/0/0 time: 2s job: 1 dft_time: 0
+	libFuzzerForkStatusRegexp = regexp.MustCompile(`^#(\d+): cov: (\d+) ft: (\d+) corp: (\d+) exec/s (\d+)`)
+	// stat::number_of_executed_units: 1048576
+	libFuzzerFinalStatRegexp = regexp.MustCompile(`^stat::(\w+):\s+(\d+)`)
+)
+
+// libFuzzerStats keeps the latest stats printed by the fuzzer until they are reported
+type libFuzzerStats struct {
+	mu      sync.Mutex
+	stats   JobStats
+	updated bool
+}
+
+func parseStatsInt(s string) int64 {
+	i, _ := strconv.ParseInt(s, 10, 64)
+	return i
+}
+
+// parseLine is called with every output line of the fuzzer
+func (s *libFuzzerStats) parseLine(line string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if match := libFuzzerStatusRegexp.FindStringSubmatch(line); match != nil {
+		s.stats.Executions = parseStatsInt(match[1])
+		s.stats.Coverage = parseStatsInt(match[2])
+		s.stats.Features = parseStatsInt(match[3])
+		s.stats.CorpusSize = parseStatsInt(match[4])
+		s.stats.ExecsPerSec = parseStatsInt(match[5])
+		rss := parseStatsInt(match[6])
+		if rss > s.stats.PeakRssMb {
+			s.stats.PeakRssMb = rss
+		}
+		s.updated = true
+	} else if match := libFuzzerForkStatusRegexp.FindStringSubmatch(line); match != nil {
+		s.stats.Executions = parseStatsInt(match[1])
+		s.stats.Coverage = parseStatsInt(match[2])
+		s.stats.Features = parseStatsInt(match[3])
+		s.stats.CorpusSize = parseStatsInt(match[4])
+		s.stats.ExecsPerSec = parseStatsInt(match[5])
+		s.updated = true
+	} else if match := libFuzzerFinalStatRegexp.FindStringSubmatch(line); match != nil {
+		value := parseStatsInt(match[2])
+		switch match[1] {
+		case "number_of_executed_units":
+			s.stats.Executions = value
+		case "average_exec_per_sec":
+			s.stats.ExecsPerSec = value
+		case "peak_rss_mb":
+			s.stats.PeakRssMb = value
+		case "new_units_added":
+			s.stats.NewUnitsAdded = value
+		default:
+			return
+		}
+		s.stats.Final = true
+		s.updated = true
+	}
+}
+
+// take returns the stats if they were updated since the last call and starts a new sample
+func (s *libFuzzerStats) take() (JobStats, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats, updated := s.stats, s.updated
+	s.updated = false
+	s.stats.Final = false
+	return stats, updated
+}
+
+// reportLibFuzzerStats adds the stats to the job. failing to report them doesn't fail the job
+func (c *FuzzitClient) reportLibFuzzerStats(stats *libFuzzerStats) {
+	sample, updated := stats.take()
+	if !updated || !c.updateDB {
+		return
+	}
+	if err := c.backend.AddJobStats(c.Org, c.currentJob.TargetId, c.jobId, sample); err != nil {
+		log.Printf("failed to report stats: %v", err)
+	}
+}
+
 func libFuzzerExitCodeToStatus(exitCode int) string {
 	status := "pass"
 	switch exitCode {
@@ -31,7 +128,8 @@ func libFuzzerExitCodeToStatus(exitCode int) string {
 	return status
 }
 
-func (c *FuzzitClient) runlibFuzzerMerge() error {
+// runlibFuzzerMerge merges the corpus with fuzzer, the command line of a libFuzzer compatible fuzzer
+func (c *FuzzitClient) runlibFuzzerMerge(fuzzer []string) error {
 	isEmpty, err := IsDirEmpty("corpus")
 	if err != nil {
 		return err
@@ -65,8 +163,9 @@ func (c *FuzzitClient) runlibFuzzerMerge() error {
 		"corpus",
 	}
 
-	log.Println("Running merge with: ./fuzzer " + strings.Join(args, " "))
-	cmd := exec.Command("./fuzzer",
+	args = append(append([]string{}, fuzzer[1:]...), args...)
+	log.Println("Running merge with: " + fuzzer[0] + " " + strings.Join(args, " "))
+	cmd := exec.Command(fuzzer[0],
 		args...)
 	if err := appendPrefixToCmd(cmd); err != nil {
 		return err
@@ -97,69 +196,269 @@ func (c *FuzzitClient) runlibFuzzerMerge() error {
 	return nil
 }
 
-func (c *FuzzitClient) uploadCrash(exitCode int) error {
-	ctx := context.Background()
+func (c *FuzzitClient) uploadCrash(exitCode int, output []string) error {
+	if _, err := os.Stat("artifact"); err == nil {
+		return c.uploadCrashFile("artifact", exitCode, output)
+	}
+
+	return nil
+}
 
+// uploadCrashFile saves the crash of the input at path, see saveCrash. output is the output of the fuzzer
+// for this input, nil if it can't be told apart from the output of other inputs. The crash report is only
+// parsed, and the crash grouped into an issue, from a known output
+func (c *FuzzitClient) uploadCrashFile(path string, exitCode int, output []string) error {
 	if !c.updateDB {
 		return nil
 	}
 
-	if _, err := os.Stat("artifact"); err == nil {
-		colRef := c.firestoreClient.Collection(fmt.Sprintf("orgs/%s/targets/%s/jobs/%s/crashes", c.Org, c.currentJob.TargetId, c.jobId))
-		crashRef := colRef.NewDoc()
+	crashId := c.backend.NewCrashId(c.Org, c.currentJob.TargetId, c.jobId)
+	report := ParseCrashReport(output)
+	lines := output
+	if lines == nil {
+		lines = getLastLines()
+	}
+	return c.saveCrash(path, fmt.Sprintf("crash-%s", c.currentJob.TargetId), crashId, Crash{
+		TargetName: c.currentJob.TargetId,
+		JobId:      c.jobId,
+		TargetId:   c.currentJob.TargetId,
+		OrgId:      c.Org,
+		ExitCode:   uint32(exitCode),
+		Type:       "crash",
+		LastLines:  strings.Join(lines, "\n"),
+		V2:         true,
+		Sanitizer:  report.Sanitizer,
+		BugType:    report.BugType,
+		AccessType: report.AccessType,
+		AccessSize: report.AccessSize,
+		Frames:     report.Frames,
+	})
+}
 
-		log.Printf("uploading crash...")
-		if err = c.uploadFile("artifact",
-			fmt.Sprintf("orgs/%s/targets/%s/jobs/%s/crashes/%s", c.Org, c.currentJob.TargetId, c.jobId, crashRef.ID),
-			fmt.Sprintf("crash-%s", c.currentJob.TargetId)); err != nil {
-			return err
+// libFuzzerWorkers returns the number of libFuzzer workers for the cpus allocated to the job
+func libFuzzerWorkers(cpus string) int {
+	n, err := strconv.ParseFloat(cpus, 64)
+	if err != nil || n < 2 {
+		return 1
+	}
+	return int(n)
+}
+
+// libFuzzerArtifactStatus maps a libFuzzer artifact to a job status and the exit code libFuzzer would
+// have exited with. other artifacts (e.g slow-unit-) are not reported
+func libFuzzerArtifactStatus(artifact string) (string, int) {
+	switch {
+	case strings.HasPrefix(artifact, "crash-"), strings.HasPrefix(artifact, "leak-"):
+		return "crash", libFuzzerCrashExitCode
+	case strings.HasPrefix(artifact, "timeout-"):
+		return "timeout", libFuzzerTimeoutExitCode
+	case strings.HasPrefix(artifact, "oom-"):
+		return "oom", libFuzzerOOMExitCode
+	}
+	return "", 0
+}
+
+// uploadLibFuzzerArtifacts uploads the artifacts written by the workers that weren't uploaded yet. uploaded
+// maps each artifact to its status
+func (c *FuzzitClient) uploadLibFuzzerArtifacts(fuzzer []string, uploaded map[string]string) error {
+	files, err := ioutil.ReadDir(libFuzzerArtifactsDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
 		}
+		return err
+	}
 
-		_, err = crashRef.Set(ctx, crash{
-			TargetName: c.currentJob.TargetId,
-			JobId:      c.jobId,
-			TargetId:   c.currentJob.TargetId,
-			OrgId:      c.Org,
-			ExitCode:   uint32(exitCode),
-			Type:       "crash",
-			LastLines:  strings.Join(lastLines, "\n"),
-			V2:         true,
-		})
-		if err != nil {
+	for _, file := range files {
+		status, exitCode := libFuzzerArtifactStatus(file.Name())
+		if _, ok := uploaded[file.Name()]; ok || status == "" || file.IsDir() {
+			continue
+		}
+		log.Printf("libFuzzer found %s %s", status, file.Name())
+		path := filepath.Join(libFuzzerArtifactsDir, file.Name())
+		// the output of the fuzzer mixes the reports of all the workers
+		output := libFuzzerWorkerOutput(file.Name())
+		if output == nil && status == "crash" {
+			output = c.replayLibFuzzerArtifact(fuzzer, path)
+		}
+		if err := c.refreshToken(); err != nil {
 			return err
 		}
+		if err := c.uploadCrashFile(path, exitCode, output); err != nil {
+			return err
+		}
+		uploaded[file.Name()] = status
 	}
 
 	return nil
 }
 
-func (c *FuzzitClient) runLibFuzzerFuzzing() error {
-	ctx := context.Background()
+// libFuzzerJobArgs returns the job args the fuzzer command line doesn't hold already e.g the jazzer flags
+func (c *FuzzitClient) libFuzzerJobArgs(fuzzer []string) []string {
+	var args []string
+	for _, arg := range splitAndRemoveEmpty(c.currentJob.Args, " ") {
+		if !Contains(fuzzer[1:], arg) {
+			args = append(args, arg)
+		}
+	}
+	return args
+}
 
-	args := append(
-		[]string{
-			"-print_final_stats=1",
-			"-exact_artifact_path=./artifact",
-			"-error_exitcode=76",
-			"-max_total_time=3600",
-			"corpus",
-			"additional-corpus",
-			"seed",
-		},
+// replayLibFuzzerArtifact runs a crashing input once more to get its own report, for -fork workers which
+// don't keep their output. It returns nil if the replay failed
+func (c *FuzzitClient) replayLibFuzzerArtifact(fuzzer []string, path string) []string {
+	ctx, cancel := context.WithTimeout(context.Background(), libFuzzerReplayTimeout)
+	defer cancel()
+
+	args := append([]string{}, fuzzer[1:]...)
+	args = append(args, c.libFuzzerJobArgs(fuzzer)...)
+	args = append(args, "-runs=1", path)
+	output, _ := exec.CommandContext(ctx, fuzzer[0], args...).CombinedOutput()
+	if ctx.Err() != nil {
+		log.Printf("failed to replay %s: %v", path, ctx.Err())
+		return nil
+	}
+	return strings.Split(string(output), "\n")
+}
+
+// libFuzzerSupportsFork checks if the fuzzer was built with a libFuzzer supporting -fork (llvm 9+)
+func libFuzzerSupportsFork(fuzzer []string) bool {
+	args := append(append([]string{}, fuzzer[1:]...), "-help=1")
+	output, _ := exec.Command(fuzzer[0], args...).CombinedOutput()
+	for _, line := range strings.Split(string(output), "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "fork ") {
+			return true
+		}
+	}
+	return false
+}
+
+// libFuzzerWorkerOutput returns the output of the -jobs worker which found artifact. workers log to
+// fuzz-<job>.log instead of the output of the fuzzer
+func libFuzzerWorkerOutput(artifact string) []string {
+	logs, err := filepath.Glob("fuzz-*.log")
+	if err != nil {
+		return nil
+	}
+	for _, logFile := range logs {
+		content, err := ioutil.ReadFile(logFile)
+		if err != nil || !strings.Contains(string(content), artifact) {
+			continue
+		}
+		lines := strings.Split(string(content), "\n")
+		if len(lines) > 1000 {
+			lines = lines[len(lines)-1000:]
+		}
+		return lines
+	}
+	return nil
+}
+
+// runLibFuzzerParallelFuzzing runs a libFuzzer worker per cpu, in fork mode if the fuzzer supports it and
+// with -jobs otherwise. crashes of the workers are collected instead of stopping at the first one and the
+// inputs found by the workers end up in the corpus, which is minimized and uploaded after each session
+func (c *FuzzitClient) runLibFuzzerParallelFuzzing(fuzzer []string, workers int) error {
+	parallelArgs := []string{
+		fmt.Sprintf("-fork=%d", workers),
+		"-ignore_crashes=1",
+		"-ignore_timeouts=1",
+		"-ignore_ooms=1",
+	}
+	if !libFuzzerSupportsFork(fuzzer) {
+		log.Println("fuzzer doesn't support -fork. running workers with -jobs")
+		parallelArgs = []string{
+			fmt.Sprintf("-jobs=%d", workers),
+			fmt.Sprintf("-workers=%d", workers),
+		}
+	}
+
+	args := append([]string{"-print_final_stats=1"}, parallelArgs...)
+	args = append(args,
+		"-artifact_prefix="+libFuzzerArtifactsDir+"/",
+		fmt.Sprintf("-max_total_time=%d", fuzzingInterval),
+		"corpus",
+		"additional-corpus",
+		"seed",
 	)
+	args = append(args, c.libFuzzerJobArgs(fuzzer)...)
+	args = append(append([]string{}, fuzzer[1:]...), args...)
+
+	if err := createDirIfNotExist(libFuzzerArtifactsDir); err != nil {
+		return err
+	}
+
+	uploaded := map[string]string{}
+	for {
+		log.Println("Running fuzzing with: " + fuzzer[0] + " " + strings.Join(args, " "))
+		cmd := exec.Command(fuzzer[0], args...)
+		stats := &libFuzzerStats{}
+		if err := appendPrefixToCmdWithCallback(cmd, stats.parseLine); err != nil {
+			return err
+		}
+
+		exitCode, cancelled, err := c.runFuzzerSession(cmd, func() error {
+			c.reportLibFuzzerStats(stats)
+			return c.uploadLibFuzzerArtifacts(fuzzer, uploaded)
+		})
+		if err != nil {
+			return err
+		}
+		if cancelled {
+			return nil
+		}
+		c.reportLibFuzzerStats(stats)
+
+		if err := c.uploadLibFuzzerArtifacts(fuzzer, uploaded); err != nil {
+			return err
+		}
+		if len(uploaded) > 0 {
+			status := ""
+			for _, artifactStatus := range uploaded {
+				if status == "" || artifactStatus == "crash" || (artifactStatus == "timeout" && status == "oom") {
+					status = artifactStatus
+				}
+			}
+			return c.transitionStatus(status)
+		}
+		if exitCode != libFuzzerSuccessExitCode {
+			return c.transitionStatus(c.exitStatus(exitCode))
+		}
+
+		if err := c.runlibFuzzerMerge(fuzzer); err != nil {
+			return err
+		}
+		log.Print("process finished successfully")
+	}
+}
 
-	if c.currentJob.Args != "" {
-		args = append(args, splitAndRemoveEmpty(c.currentJob.Args, " ")...)
+func (c *FuzzitClient) runLibFuzzerFuzzing(fuzzer []string) error {
+	if workers := libFuzzerWorkers(c.currentJob.CPUs); workers > 1 {
+		return c.runLibFuzzerParallelFuzzing(fuzzer, workers)
 	}
 
+	args := []string{
+		"-print_final_stats=1",
+		"-exact_artifact_path=./artifact",
+		"-error_exitcode=76",
+		"-max_total_time=3600",
+		"corpus",
+		"additional-corpus",
+		"seed",
+	}
+
+	args = append(args, c.libFuzzerJobArgs(fuzzer)...)
+
+	args = append(append([]string{}, fuzzer[1:]...), args...)
+
 	var err error
 	err = nil
 	var exitCode int
 	for err == nil {
-		log.Println("Running fuzzing with: ./fuzzer " + strings.Join(args, " "))
-		cmd := exec.Command("./fuzzer",
+		log.Println("Running fuzzing with: " + fuzzer[0] + " " + strings.Join(args, " "))
+		cmd := exec.Command(fuzzer[0],
 			args...)
-		if err := appendPrefixToCmd(cmd); err != nil {
+		stats := &libFuzzerStats{}
+		if err := appendPrefixToCmdWithCallback(cmd, stats.parseLine); err != nil {
 			return err
 		}
 		err = cmd.Start()
@@ -171,31 +470,24 @@ func (c *FuzzitClient) runLibFuzzerFuzzing() error {
 		for !stopSession {
 			select {
 			case <-timeout:
-				var fuzzingJob Job
 				if err := c.refreshToken(); err != nil {
 					return err
 				}
-				docRef := c.firestoreClient.Doc(fmt.Sprintf("orgs/%s/targets/%s/jobs/%s", c.Org, c.currentJob.TargetId, c.jobId))
-				if docRef == nil {
-					return fmt.Errorf("invalid resource")
-				}
-				docsnap, err := docRef.Get(ctx)
-				if err != nil {
-					return err
-				}
-				err = docsnap.DataTo(&fuzzingJob)
+				fuzzingJob, err := c.backend.GetJob(c.Org, c.currentJob.TargetId, c.jobId)
 				if err != nil {
 					return err
 				}
 				if fuzzingJob.Status == "in progress" {
+					c.reportLibFuzzerStats(stats)
 					timeout = time.After(60 * time.Second)
 				} else {
 					log.Println("job was cancel by user. exiting...")
 					cmd.Process.Kill()
-					return nil
+					return c.acknowledgeCancel(fuzzingJob.Status)
 				}
 			case err = <-done:
 				stopSession = true
+				c.reportLibFuzzerStats(stats)
 				if err != nil {
 					log.Printf("process finished with error = %v\n", err)
 					if exiterr, ok := err.(*exec.ExitError); ok {
@@ -213,7 +505,7 @@ func (c *FuzzitClient) runLibFuzzerFuzzing() error {
 						return err
 					}
 				} else {
-					if err = c.runlibFuzzerMerge(); err != nil {
+					if err = c.runlibFuzzerMerge(fuzzer); err != nil {
 						return err
 					}
 					log.Print("process finished successfully")
@@ -225,12 +517,12 @@ func (c *FuzzitClient) runLibFuzzerFuzzing() error {
 	if err := c.refreshToken(); err != nil {
 		return err
 	}
-	err = c.uploadCrash(exitCode)
+	err = c.uploadCrash(exitCode, getLastLines())
 	if err != nil {
 		return err
 	}
 
-	err = c.transitionStatus(libFuzzerExitCodeToStatus(exitCode))
+	err = c.transitionStatus(c.exitStatus(exitCode))
 	if err != nil {
 		return err
 	}
@@ -238,7 +530,7 @@ func (c *FuzzitClient) runLibFuzzerFuzzing() error {
 	return nil
 }
 
-func (c *FuzzitClient) runLibFuzzerRegression() error {
+func (c *FuzzitClient) runLibFuzzerRegression(fuzzer []string) error {
 	var corpusFiles []string
 	var seedFiles []string
 
@@ -271,12 +563,11 @@ func (c *FuzzitClient) runLibFuzzerRegression() error {
 		},
 		regressionFiles...,
 	)
-	if c.currentJob.Args != "" {
-		args = append(args, splitAndRemoveEmpty(c.currentJob.Args, " ")...)
-	}
+	args = append(args, c.libFuzzerJobArgs(fuzzer)...)
 
+	args = append(append([]string{}, fuzzer[1:]...), args...)
 	log.Println("Running regression...")
-	cmd := exec.Command("./fuzzer",
+	cmd := exec.Command(fuzzer[0],
 		args...)
 	if err := appendPrefixToCmd(cmd); err != nil {
 		return err
@@ -304,11 +595,11 @@ func (c *FuzzitClient) runLibFuzzerRegression() error {
 		}
 	}
 
-	if err := c.uploadCrash(exitCode); err != nil {
+	if err := c.uploadCrash(exitCode, getLastLines()); err != nil {
 		return err
 	}
 
-	err = c.transitionStatus(libFuzzerExitCodeToStatus(exitCode))
+	err = c.transitionStatus(c.exitStatus(exitCode))
 	if err != nil {
 		return err
 	}
@@ -316,21 +607,44 @@ func (c *FuzzitClient) runLibFuzzerRegression() error {
 	return nil
 }
 
-func (c *FuzzitClient) runLibFuzzer() error {
+type libFuzzerEngine struct{}
+
+func init() {
+	RegisterEngine("libfuzzer", libFuzzerEngine{})
+}
+
+func (libFuzzerEngine) Prepare(c *FuzzitClient) error {
 	if _, err := os.Stat("fuzzer"); os.IsNotExist(err) {
 		c.transitionStatus("failed")
 		return fmt.Errorf("fuzzer executable doesnt exist")
 	}
-	if err := os.Chmod("./fuzzer", 0770); err != nil {
-		return err
-	}
+	return os.Chmod("./fuzzer", 0770)
+}
 
-	var err error
-	if c.currentJob.Type == "regression" {
-		err = c.runLibFuzzerRegression()
-	} else {
-		err = c.runLibFuzzerFuzzing()
-	}
+func (libFuzzerEngine) Fuzz(c *FuzzitClient) error {
+	return c.runLibFuzzerFuzzing([]string{"./fuzzer"})
+}
+
+func (libFuzzerEngine) Regress(c *FuzzitClient) error {
+	return c.runLibFuzzerRegression([]string{"./fuzzer"})
+}
+
+func (libFuzzerEngine) Merge(c *FuzzitClient) error {
+	return c.runlibFuzzerMerge([]string{"./fuzzer"})
+}
+
+func (libFuzzerEngine) MinimizeCrash(c *FuzzitClient, path string, dst string, runs int) error {
+	return c.minimizeLibFuzzerCrash([]string{"./fuzzer"}, path, dst, runs)
+}
+
+func (libFuzzerEngine) ClassifyExit(exitCode int) string {
+	return libFuzzerExitCodeToStatus(exitCode)
+}
+
+func (libFuzzerEngine) DefaultHost() string {
+	return "gcr.io/fuzzit-public/stretch-llvm8:64bdedf"
+}
 
-	return err
+func (libFuzzerEngine) RawFuzzer() string {
+	return ""
 }
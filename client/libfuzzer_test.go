@@ -0,0 +1,62 @@
+package client
+
+import "testing"
+
+func TestLibFuzzerWorkers(t *testing.T) {
+	workers := map[string]int{"": 1, "0.5": 1, "1": 1, "1.0": 1, "2": 2, "8": 8}
+	for cpus, expected := range workers {
+		if received := libFuzzerWorkers(cpus); received != expected {
+			t.Errorf("%q cpus: expected %d workers received %d", cpus, expected, received)
+		}
+	}
+
+	statuses := map[string]string{
+		"crash-df779ced6b712c5fca247e465de2de474d1d23b9":     "crash",
+		"leak-df779ced6b712c5fca247e465de2de474d1d23b9":      "crash",
+		"timeout-df779ced6b712c5fca247e465de2de474d1d23b9":   "timeout",
+		"oom-df779ced6b712c5fca247e465de2de474d1d23b9":       "oom",
+		"slow-unit-df779ced6b712c5fca247e465de2de474d1d23b9": "",
+	}
+	for artifact, expected := range statuses {
+		if received, _ := libFuzzerArtifactStatus(artifact); received != expected {
+			t.Errorf("%s: expected %q received %q", artifact, expected, received)
+		}
+	}
+}
+
+func TestLibFuzzerStats(t *testing.T) {
+	stats := &libFuzzerStats{}
+	if _, updated := stats.take(); updated {
+		t.Error("expected no stats before any output")
+	}
+
+	stats.parseLine("#1048576\tpulse  cov: 23 ft: 24 corp: 4/16b lim: 4096 exec/s: 349525 rss: 29Mb")
+	sample, updated := stats.take()
+	if !updated || sample.Final {
+		t.Fatalf("expected a periodic sample received %+v", sample)
+	}
+	if sample.Executions != 1048576 || sample.Coverage != 23 || sample.Features != 24 ||
+		sample.CorpusSize != 4 || sample.ExecsPerSec != 349525 || sample.PeakRssMb != 29 {
+		t.Errorf("unexpected periodic sample %+v", sample)
+	}
+
+	stats.parseLine("#2048: cov: 30 ft: 31 corp: 5 exec/s 1024 oom/timeout/crash: 0/0/0 time: 2s job: 1 dft_time: 0")
+	if sample, _ = stats.take(); sample.Coverage != 30 || sample.ExecsPerSec != 1024 || sample.Executions != 2048 {
+		t.Errorf("unexpected fork sample %+v", sample)
+	}
+
+	for _, line := range []string{
+		"stat::number_of_executed_units: 4096",
+		"stat::average_exec_per_sec:     2048",
+		"stat::new_units_added:          3",
+		"stat::slowest_unit_time_sec:    0",
+		"stat::peak_rss_mb:              35",
+	} {
+		stats.parseLine(line)
+	}
+	sample, updated = stats.take()
+	if !updated || !sample.Final || sample.Executions != 4096 || sample.ExecsPerSec != 2048 ||
+		sample.NewUnitsAdded != 3 || sample.PeakRssMb != 35 {
+		t.Errorf("unexpected final sample %+v", sample)
+	}
+}
@@ -0,0 +1,220 @@
+package client
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	logsInterval = 10 * time.Second
+	// keeps a chunk well below the 1 MiB document size limit of firestore
+	maxLogsChunkBytes = 512 << 10
+	// longer lines are truncated so a single line always fits in a chunk
+	maxLogsLineBytes = 64 << 10
+	// the oldest lines are dropped when the backend is unavailable for long enough to buffer this much
+	maxLogsBufferBytes = 16 << 20
+	// the logs left when the agent stops are shipped this many times before they are dropped
+	finalLogsAttempts = 3
+)
+
+// logsBuffer keeps the output lines of the fuzzer and the log lines of the agent that weren't shipped to the
+// backend yet. lines are only kept while the logs are shipped. size is the number of bytes of lines
+type logsBuffer struct {
+	mu       sync.Mutex
+	shipping bool
+	lines    []string
+	size     int
+	offset   int64
+}
+
+// logLineSize is the size of a line in a chunk, firestore counts one more byte per string
+func logLineSize(line string) int {
+	return len(line) + 1
+}
+
+var jobLogs = &logsBuffer{}
+
+func (b *logsBuffer) add(line string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.shipping {
+		return
+	}
+	if len(line) > maxLogsLineBytes {
+		line = line[:maxLogsLineBytes]
+	}
+	b.lines = append(b.lines, line)
+	b.size += logLineSize(line)
+
+	// the dropped lines are skipped by the offset of the next chunk
+	dropped := 0
+	for b.size > maxLogsBufferBytes {
+		b.size -= logLineSize(b.lines[dropped])
+		dropped++
+	}
+	if dropped > 0 {
+		b.lines = b.lines[dropped:]
+		b.offset += int64(dropped)
+	}
+}
+
+func (b *logsBuffer) setShipping(shipping bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.shipping = shipping
+}
+
+// logsWriter adds the lines written to it to the job logs, for the log output of the agent
+type logsWriter struct{}
+
+func (logsWriter) Write(p []byte) (int, error) {
+	for _, line := range strings.Split(strings.TrimSuffix(string(p), "\n"), "\n") {
+		jobLogs.add(line)
+	}
+	return len(p), nil
+}
+
+// take returns the next chunk of lines and the number of lines taken before it
+func (b *logsBuffer) take() (int64, []string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	n, size := 0, 0
+	for n < len(b.lines) && size+logLineSize(b.lines[n]) <= maxLogsChunkBytes {
+		size += logLineSize(b.lines[n])
+		n++
+	}
+	offset, lines := b.offset, b.lines[:n]
+	b.lines = b.lines[n:]
+	b.size -= size
+	b.offset += int64(n)
+	return offset, lines
+}
+
+// putBack returns a chunk returned by take which couldn't be shipped so it's taken again. The chunk is
+// dropped if lines were dropped after it meanwhile, as it's older than them
+func (b *logsBuffer) putBack(offset int64, lines []string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.offset != offset+int64(len(lines)) {
+		return
+	}
+	b.lines = append(append([]string{}, lines...), b.lines...)
+	for _, line := range lines {
+		b.size += logLineSize(line)
+	}
+	b.offset = offset
+}
+
+// logsId names the chunk starting at offset so chunks are listed in order
+func logsId(offset int64) string {
+	return fmt.Sprintf("%012d", offset)
+}
+
+// shipLogs writes the buffered output to the logs collection of the job. A chunk which fails to ship is
+// kept for the next call
+func (c *FuzzitClient) shipLogs() error {
+	for {
+		offset, lines := jobLogs.take()
+		if len(lines) == 0 {
+			return nil
+		}
+		logs := JobLogs{Offset: offset, Lines: lines}
+		if err := c.backend.SetJobLogs(c.Org, c.currentJob.TargetId, c.jobId, logsId(offset), logs); err != nil {
+			jobLogs.putBack(offset, lines)
+			return err
+		}
+	}
+}
+
+// startShippingLogs ships the output of the fuzzer and the log output of the agent every logsInterval.
+// The returned function ships what's left and stops
+func (c *FuzzitClient) startShippingLogs() func() {
+	jobLogs.setShipping(true)
+	log.SetOutput(io.MultiWriter(os.Stderr, logsWriter{}))
+	stop := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		for {
+			select {
+			case <-stop:
+				for attempt := 1; attempt <= finalLogsAttempts; attempt++ {
+					err := c.shipLogs()
+					if err == nil {
+						return
+					}
+					fmt.Fprintf(os.Stderr, "failed to ship logs (attempt %d/%d): %v\n", attempt, finalLogsAttempts, err)
+					time.Sleep(time.Second)
+				}
+				return
+			case <-time.After(logsInterval):
+				if err := c.shipLogs(); err != nil {
+					log.Printf("failed to ship logs: %v. retrying...", err)
+				}
+			}
+		}
+	}()
+
+	return func() {
+		log.SetOutput(os.Stderr)
+		jobLogs.setShipping(false)
+		close(stop)
+		<-stopped
+	}
+}
+
+// PrintJobLogs prints the logs shipped by a job. With follow it keeps printing new logs until the job finishes
+func (c *FuzzitClient) PrintJobLogs(targetId string, jobId string, follow bool) error {
+	lastId := ""
+	finished := false
+	for {
+		if err := c.refreshToken(); err != nil {
+			return err
+		}
+
+		// the status is read before the logs so a finished job is followed by one more read which
+		// gets the chunks shipped while the job finished
+		job, err := c.backend.GetJob(c.Org, targetId, jobId)
+		if err == ErrNotFound {
+			return fmt.Errorf("job %s/%s doesn't exist", targetId, jobId)
+		}
+		if err != nil {
+			return err
+		}
+
+		// chunks are named in order so only the ones after the last printed chunk are read
+		logsPath := fmt.Sprintf("orgs/%s/targets/%s/jobs/%s/logs", c.Org, targetId, jobId)
+		var docs []map[string]interface{}
+		if lastId == "" {
+			docs, err = c.backend.ListDocuments(logsPath)
+		} else {
+			docs, _, err = c.backend.QueryDocuments(logsPath, Query{PageToken: lastId})
+		}
+		if err != nil {
+			return err
+		}
+		for _, doc := range docs {
+			id, _ := doc["id"].(string)
+			if id <= lastId {
+				continue
+			}
+			lines, _ := doc["lines"].([]interface{})
+			for _, line := range lines {
+				fmt.Println(line)
+			}
+			lastId = id
+		}
+
+		if !follow || finished {
+			return nil
+		}
+		finished = job.Status != "queued" && job.Status != "in progress"
+		time.Sleep(logsInterval)
+	}
+}
@@ -0,0 +1,105 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestShipLogs(t *testing.T) {
+	c, backend, _, cleanup := newFileClient(t)
+	defer cleanup()
+	c.currentJob = Job{TargetId: "parse-complex"}
+	c.jobId = "job"
+	jobLogs = &logsBuffer{}
+
+	jobLogs.add("dropped while not shipping")
+	stopShippingLogs := c.startShippingLogs()
+	// a chunk holds chunkLines lines of 1 KiB
+	chunkLines := maxLogsChunkBytes / 1024
+	for i := 0; i < chunkLines; i++ {
+		jobLogs.add(fmt.Sprintf("line %-1018d", i))
+	}
+	log.Print("agent line")
+	stopShippingLogs()
+
+	docs, err := backend.ListDocuments("orgs/fuzzitdev/targets/parse-complex/jobs/job/logs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) != 2 || docs[0]["id"] != logsId(0) || docs[1]["id"] != logsId(int64(chunkLines)) {
+		t.Fatalf("expected 2 chunks received %d", len(docs))
+	}
+	first, _ := docs[0]["lines"].([]interface{})
+	last, _ := docs[1]["lines"].([]interface{})
+	if len(first) != chunkLines || !strings.HasPrefix(first[0].(string), "line 0 ") || len(last) != 1 || !strings.HasSuffix(last[0].(string), "agent line") {
+		t.Errorf("unexpected chunks %d %v", len(first), last)
+	}
+
+	// a chunk which fails to ship is shipped by the next call
+	c.backend = &failingLogsBackend{Backend: backend, failures: 1}
+	c.jobId = "retried"
+	jobLogs.setShipping(true)
+	jobLogs.add("retried line")
+	jobLogs.setShipping(false)
+	if err := c.shipLogs(); err == nil {
+		t.Error("was expecting the first shipment to fail")
+	}
+	if err := c.shipLogs(); err != nil {
+		t.Fatal(err)
+	}
+	docs, err = backend.ListDocuments("orgs/fuzzitdev/targets/parse-complex/jobs/retried/logs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) != 1 || docs[0]["id"] != logsId(int64(chunkLines)+1) {
+		t.Errorf("expected the retried chunk received %v", docs)
+	}
+}
+
+// failingLogsBackend fails to set the logs of a job the first failures times
+type failingLogsBackend struct {
+	Backend
+	failures int
+}
+
+func (b *failingLogsBackend) SetJobLogs(org string, targetId string, jobId string, logsId string, logs JobLogs) error {
+	if b.failures > 0 {
+		b.failures--
+		return errors.New("unavailable")
+	}
+	return b.Backend.SetJobLogs(org, targetId, jobId, logsId, logs)
+}
+
+func TestLogsBufferLimit(t *testing.T) {
+	b := &logsBuffer{shipping: true}
+	line := strings.Repeat("x", 1023)
+	bufferLines := maxLogsBufferBytes / 1024
+	for i := 0; i < bufferLines+10; i++ {
+		b.add(line)
+	}
+
+	// the oldest lines are dropped to make room for the new ones
+	offset, lines := b.take()
+	if offset != 10 || len(lines) != maxLogsChunkBytes/1024 {
+		t.Fatalf("expected a chunk of %d lines at 10 received %d lines at %d", maxLogsChunkBytes/1024, len(lines), offset)
+	}
+
+	// a chunk older than dropped lines isn't put back
+	for i := 0; i <= len(lines); i++ {
+		b.add(line)
+	}
+	b.putBack(offset, lines)
+	if next, _ := b.take(); next != offset+int64(len(lines))+1 {
+		t.Errorf("expected the chunk to be dropped received offset %d", next)
+	}
+
+	// too long lines are truncated
+	b = &logsBuffer{shipping: true}
+	b.add(strings.Repeat("x", maxLogsChunkBytes))
+	if _, lines := b.take(); len(lines) != 1 || len(lines[0]) != maxLogsLineBytes {
+		t.Errorf("expected a truncated line received %d lines", len(lines))
+	}
+}
@@ -0,0 +1,158 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+const (
+	// minimizeTimeout bounds the minimization of a crash, whatever the number of runs
+	minimizeTimeout = 10 * time.Minute
+	// minimizeRunTimeout bounds each run of the engines minimized by removing chunks of the input. Candidates
+	// running longer aren't considered crashing
+	minimizeRunTimeout = 30 * time.Second
+)
+
+// CrashMinimizer is implemented by engines that can minimize a crashing input. go-fuzz doesn't need it
+// as it minimizes crashers itself before writing them to workdir/crashers
+type CrashMinimizer interface {
+	// MinimizeCrash writes a smaller input still crashing the fuzzer than the input at path to dst.
+	// runs bounds the executions of the fuzzer, it's passed as is to libFuzzer as -runs
+	MinimizeCrash(c *FuzzitClient, path string, dst string, runs int) error
+}
+
+// minimizeCrash minimizes the crashing input at path, if the engine supports it, and uploads the result
+// to storagePath as filename. A failed minimization is only logged so the crash isn't lost. It returns
+// false if the input wasn't minimized
+func (c *FuzzitClient) minimizeCrash(path string, storagePath string, filename string) (bool, error) {
+	engine, err := GetEngine(c.currentJob.Engine)
+	if err != nil {
+		return false, err
+	}
+	minimizer, ok := engine.(CrashMinimizer)
+	if !ok {
+		return false, nil
+	}
+
+	// minimized inputs are kept away from the directories the engines look for crashes in
+	dir, err := ioutil.TempDir("", "fuzzit-minimize")
+	if err != nil {
+		return false, err
+	}
+	defer os.RemoveAll(dir)
+	dst := filepath.Join(dir, "crash")
+
+	log.Printf("minimizing crash with %d runs...", c.currentJob.MinimizeRuns)
+	if err := minimizer.MinimizeCrash(c, path, dst, c.currentJob.MinimizeRuns); err != nil {
+		log.Printf("failed to minimize crash: %v", err)
+		return false, nil
+	}
+
+	original, err := os.Stat(path)
+	if err != nil {
+		return false, err
+	}
+	minimized, err := os.Stat(dst)
+	if err != nil || minimized.Size() >= original.Size() {
+		log.Println("crash couldn't be minimized")
+		return false, nil
+	}
+	log.Printf("crash minimized from %d to %d bytes", original.Size(), minimized.Size())
+
+	if err := c.uploadFile(dst, storagePath, filename); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// minimizeInput removes chunks of data, halving their size down to a byte, as long as crashes still
+// reports a crash. crashes is called at most runs times
+func minimizeInput(data []byte, runs int, crashes func(input []byte) (bool, error)) ([]byte, error) {
+	for chunk := len(data) / 2; chunk > 0 && runs > 0; chunk /= 2 {
+		for start := 0; start < len(data) && runs > 0; {
+			end := start + chunk
+			if end > len(data) {
+				end = len(data)
+			}
+			candidate := append(append([]byte{}, data[:start]...), data[end:]...)
+			runs--
+			crashed, err := crashes(candidate)
+			if err != nil {
+				return nil, err
+			}
+			if crashed {
+				data = candidate
+			} else {
+				start = end
+			}
+		}
+	}
+	return data, nil
+}
+
+// minimizeInputFile runs minimizeInput on the file at path and writes the result to dst. The candidates
+// are written to dst before crashes is called with its path
+func minimizeInputFile(path string, dst string, runs int, crashes func(candidate string) (bool, error)) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	minimized, err := minimizeInput(data, runs, func(input []byte) (bool, error) {
+		if err := ioutil.WriteFile(dst, input, 0644); err != nil {
+			return false, err
+		}
+		return crashes(dst)
+	})
+	if err != nil {
+		os.Remove(dst)
+		return err
+	}
+	if bytes.Equal(minimized, data) {
+		os.Remove(dst)
+		return nil
+	}
+	return ioutil.WriteFile(dst, minimized, 0644)
+}
+
+// minimizeLibFuzzerCrash minimizes path with -minimize_crash which runs the fuzzer up to runs times per attempt
+// for at most minimizeTimeout
+func (c *FuzzitClient) minimizeLibFuzzerCrash(fuzzer []string, path string, dst string, runs int) error {
+	// libFuzzer stops at -max_total_time after its current attempt, the context kills attempts which hang
+	ctx, cancel := context.WithTimeout(context.Background(), minimizeTimeout+time.Minute)
+	defer cancel()
+
+	args := append([]string{}, fuzzer[1:]...)
+	args = append(args, c.libFuzzerJobArgs(fuzzer)...)
+	args = append(args,
+		"-minimize_crash=1",
+		"-runs="+strconv.Itoa(runs),
+		fmt.Sprintf("-max_total_time=%d", int(minimizeTimeout.Seconds())),
+		// intermediate inputs are written to the artifact prefix
+		"-artifact_prefix="+filepath.Dir(dst)+string(filepath.Separator),
+		"-exact_artifact_path="+dst,
+		path,
+	)
+
+	output, err := exec.CommandContext(ctx, fuzzer[0], args...).CombinedOutput()
+	if err != nil {
+		// the last lines tell why libFuzzer gave up e.g the input didn't crash
+		lines := splitAndRemoveEmpty(string(output), "\n")
+		if len(lines) > 3 {
+			lines = lines[len(lines)-3:]
+		}
+		for _, line := range lines {
+			log.Println(line)
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package client
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestMinimizeInput(t *testing.T) {
+	crashes := func(input []byte) (bool, error) {
+		return bytes.Contains(input, []byte("FUZZ")), nil
+	}
+	input := []byte("some input before FUZZ and some input after it")
+
+	minimized, err := minimizeInput(input, 1000, crashes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(minimized) != "FUZZ" {
+		t.Errorf("expected FUZZ received %q", minimized)
+	}
+
+	runs := 0
+	limited, err := minimizeInput(input, 3, func(input []byte) (bool, error) {
+		runs++
+		return crashes(input)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runs != 3 || !bytes.Contains(limited, []byte("FUZZ")) || len(limited) >= len(input) {
+		t.Errorf("unexpected minimization with 3 runs: %q after %d runs", limited, runs)
+	}
+}
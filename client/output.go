@@ -0,0 +1,251 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+
+	"gopkg.in/yaml.v2"
+)
+
+type outputColumn struct {
+	header string
+	field  string
+}
+
+// outputColumns are the table columns of each collection. Other collections show all the fields
+var outputColumns = map[string][]outputColumn{
+	"targets": {
+		{"ID", "id"},
+		{"PUBLIC CORPUS", "public_corpus"},
+	},
+	"jobs": {
+		{"ID", "id"},
+		{"STATUS", "status"},
+		{"TYPE", "type"},
+		{"ENGINE", "engine"},
+		{"BRANCH", "branch"},
+		{"REVISION", "revision"},
+		{"STARTED", "started_at"},
+	},
+	"crashes": {
+		{"ID", "id"},
+		{"JOB", "job_id"},
+		{"TYPE", "type"},
+		{"BUG", "bug_type"},
+		{"EXIT CODE", "exit_code"},
+		{"TIME", "time"},
+	},
+	"issues": {
+		{"ID", "id"},
+		{"BUG", "bug_type"},
+		{"COUNT", "count"},
+		{"FIRST SEEN", "first_seen"},
+		{"LAST SEEN", "last_seen"},
+		{"CRASH", "crash_id"},
+	},
+	"stats": {
+		{"TIME", "time"},
+		{"EXECUTIONS", "number_of_executed_units"},
+		{"EXEC/S", "average_exec_per_sec"},
+		{"COV", "cov"},
+		{"FT", "ft"},
+		{"CORPUS", "corp"},
+		{"PEAK RSS MB", "peak_rss_mb"},
+	},
+}
+
+// normalizeResource converts the documents of any backend to the values decoded from JSON so
+// all the output formats see the same types
+func normalizeResource(resource interface{}) (interface{}, error) {
+	data, err := json.Marshal(resource)
+	if err != nil {
+		return nil, err
+	}
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// printResource prints a document or a list of documents of collection in the output format:
+// json, yaml, table, jsonpath=<expr> or go-template=<template>
+func printResource(w io.Writer, output string, collection string, resource interface{}) error {
+	value, err := normalizeResource(resource)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case output == "" || output == "json":
+		data, err := json.MarshalIndent(value, "", " ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	case output == "yaml":
+		data, err := yaml.Marshal(value)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	case output == "table":
+		return printTable(w, collection, value)
+	case strings.HasPrefix(output, "jsonpath="):
+		results, err := evalJSONPath(strings.TrimPrefix(output, "jsonpath="), value)
+		if err != nil {
+			return err
+		}
+		var formatted []string
+		for _, result := range results {
+			formatted = append(formatted, formatOutputValue(result))
+		}
+		_, err = fmt.Fprintln(w, strings.Join(formatted, " "))
+		return err
+	case strings.HasPrefix(output, "go-template="):
+		tmpl, err := template.New("output").Parse(strings.TrimPrefix(output, "go-template="))
+		if err != nil {
+			return err
+		}
+		return tmpl.Execute(w, value)
+	default:
+		return fmt.Errorf("unsupported output format %s. Options: json/yaml/table/jsonpath=<expr>/go-template=<template>", output)
+	}
+}
+
+// formatOutputValue prints strings and numbers as is and everything else as JSON
+func formatOutputValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+}
+
+func printTable(w io.Writer, collection string, value interface{}) error {
+	var docs []map[string]interface{}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		docs = append(docs, v)
+	case []interface{}:
+		for _, doc := range v {
+			if m, ok := doc.(map[string]interface{}); ok {
+				docs = append(docs, m)
+			}
+		}
+	}
+
+	columns, ok := outputColumns[collection]
+	if !ok {
+		fields := map[string]bool{}
+		for _, doc := range docs {
+			for field := range doc {
+				if field != "id" {
+					fields[field] = true
+				}
+			}
+		}
+		columns = []outputColumn{{"ID", "id"}}
+		var sorted []string
+		for field := range fields {
+			sorted = append(sorted, field)
+		}
+		sort.Strings(sorted)
+		for _, field := range sorted {
+			columns = append(columns, outputColumn{strings.ToUpper(field), field})
+		}
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 8, 3, ' ', 0)
+	var headers []string
+	for _, column := range columns {
+		headers = append(headers, column.header)
+	}
+	fmt.Fprintln(tw, strings.Join(headers, "\t"))
+	for _, doc := range docs {
+		var row []string
+		for _, column := range columns {
+			row = append(row, formatOutputValue(doc[column.field]))
+		}
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+// evalJSONPath evaluates a kubectl style expression such as {.status} or {[*].id} on value.
+// Field names, indexes and [*] are supported
+func evalJSONPath(expr string, value interface{}) ([]interface{}, error) {
+	expr = strings.TrimSpace(expr)
+	expr = strings.TrimSuffix(strings.TrimPrefix(expr, "{"), "}")
+
+	results := []interface{}{value}
+	for expr != "" {
+		var step string
+		var next []interface{}
+		switch expr[0] {
+		case '.':
+			end := strings.IndexAny(expr[1:], ".[")
+			if end == -1 {
+				step, expr = expr[1:], ""
+			} else {
+				step, expr = expr[1:end+1], expr[end+1:]
+			}
+			if step == "" {
+				continue
+			}
+			for _, result := range results {
+				m, ok := result.(map[string]interface{})
+				if !ok {
+					return nil, fmt.Errorf("%s is not found", step)
+				}
+				field, ok := m[step]
+				if !ok {
+					return nil, fmt.Errorf("%s is not found", step)
+				}
+				next = append(next, field)
+			}
+		case '[':
+			end := strings.Index(expr, "]")
+			if end == -1 {
+				return nil, fmt.Errorf("unterminated [ in jsonpath")
+			}
+			step, expr = expr[1:end], expr[end+1:]
+			for _, result := range results {
+				list, ok := result.([]interface{})
+				if !ok {
+					return nil, fmt.Errorf("[%s] is not an array", step)
+				}
+				if step == "*" {
+					next = append(next, list...)
+					continue
+				}
+				i, err := strconv.Atoi(step)
+				if err != nil || i < 0 || i >= len(list) {
+					return nil, fmt.Errorf("array index [%s] out of bounds", step)
+				}
+				next = append(next, list[i])
+			}
+		default:
+			return nil, fmt.Errorf("unsupported jsonpath %s", expr)
+		}
+		results = next
+	}
+
+	return results, nil
+}
@@ -0,0 +1,59 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestPrintResource(t *testing.T) {
+	jobs := []map[string]interface{}{
+		{"id": "job-a", "status": "crash", "engine": "libfuzzer", "type": "fuzzing", "completed": 1},
+		{"id": "job-b", "status": "pass", "engine": "afl", "type": "regression", "completed": 0},
+	}
+
+	var out bytes.Buffer
+	if err := printResource(&out, "json", "jobs", jobs); err != nil {
+		t.Fatal(err)
+	}
+	var decoded []map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil || len(decoded) != 2 {
+		t.Errorf("expected a json array of 2 jobs received %s", out.String())
+	}
+
+	testCases := []struct {
+		output   string
+		expected string
+	}{
+		{"jsonpath={[*].status}", "crash pass\n"},
+		{"jsonpath={[1].completed}", "0\n"},
+		{"go-template={{range .}}{{.id}}:{{.engine}} {{end}}", "job-a:libfuzzer job-b:afl "},
+		{"yaml", "- completed: 1\n  engine: libfuzzer\n  id: job-a\n  status: crash\n  type: fuzzing\n" +
+			"- completed: 0\n  engine: afl\n  id: job-b\n  status: pass\n  type: regression\n"},
+	}
+	for _, tc := range testCases {
+		out.Reset()
+		if err := printResource(&out, tc.output, "jobs", jobs); err != nil {
+			t.Errorf("%s: %v", tc.output, err)
+		} else if out.String() != tc.expected {
+			t.Errorf("%s: expected %q received %q", tc.output, tc.expected, out.String())
+		}
+	}
+
+	out.Reset()
+	if err := printResource(&out, "table", "jobs", jobs); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "ID") || !strings.Contains(lines[1], "crash") {
+		t.Errorf("unexpected table %q", out.String())
+	}
+
+	if err := printResource(&out, "jsonpath={.missing}", "jobs", jobs[0]); err == nil {
+		t.Error("was expecting an error for a missing field")
+	}
+	if err := printResource(&out, "xml", "jobs", jobs); err == nil {
+		t.Error("was expecting an error for an unsupported output")
+	}
+}
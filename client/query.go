@@ -0,0 +1,259 @@
+package client
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// QueryFilter matches the documents whose field equals value
+type QueryFilter struct {
+	Field string
+	Value string
+}
+
+// Query filters, orders and pages the documents of a collection
+type Query struct {
+	Filters []QueryFilter
+	// SinceField and Since keep the documents with SinceField at or after Since
+	SinceField string
+	Since      time.Time
+	// OrderBy is the field to order by, descending if prefixed with "-". documents are ordered by id otherwise
+	OrderBy string
+	Limit   int
+	// PageToken is the id of the last document of the previous page
+	PageToken string
+}
+
+// DocumentsPage is a page of the documents of a collection, returned by the server for queries
+type DocumentsPage struct {
+	Documents     []map[string]interface{} `json:"documents"`
+	NextPageToken string                   `json:"next_page_token"`
+}
+
+// documentFieldKinds are the kinds of the bool and integer fields of the documents, by field name
+var documentFieldKinds = fieldKinds(Target{}, Job{}, Crash{}, Issue{}, JobStats{}, JobLogs{})
+
+func fieldKinds(docs ...interface{}) map[string]reflect.Kind {
+	kinds := map[string]reflect.Kind{}
+	for _, doc := range docs {
+		t := reflect.TypeOf(doc)
+		for i := 0; i < t.NumField(); i++ {
+			name := strings.Split(t.Field(i).Tag.Get("firestore"), ",")[0]
+			switch kind := t.Field(i).Type.Kind(); kind {
+			case reflect.Bool:
+				kinds[name] = reflect.Bool
+			case reflect.Int, reflect.Int64, reflect.Uint16, reflect.Uint32:
+				kinds[name] = reflect.Int64
+			}
+		}
+	}
+	return kinds
+}
+
+// typedValue returns the value of the filter as a bool or an integer for the fields of the documents stored
+// as such, backends storing typed values compare it as such. Other values are compared as strings, even if
+// they look like numbers e.g a short revision
+func (f QueryFilter) typedValue() interface{} {
+	switch documentFieldKinds[f.Field] {
+	case reflect.Bool:
+		if b, err := strconv.ParseBool(f.Value); err == nil {
+			return b
+		}
+	case reflect.Int64:
+		if i, err := strconv.ParseInt(f.Value, 10, 64); err == nil {
+			return i
+		}
+	}
+	return f.Value
+}
+
+// matches compares the filter with the field value of a document decoded from JSON, where numbers are
+// float64, the same way typed backends compare typedValue
+func (f QueryFilter) matches(value interface{}) bool {
+	switch v := value.(type) {
+	case string:
+		return v == f.Value
+	case bool:
+		b, ok := f.typedValue().(bool)
+		return ok && b == v
+	case float64:
+		i, ok := f.typedValue().(int64)
+		return ok && float64(i) == v
+	}
+	return false
+}
+
+// ParseQueryFilter parses a field=value filter
+func ParseQueryFilter(filter string) (QueryFilter, error) {
+	split := strings.SplitN(filter, "=", 2)
+	if len(split) != 2 || split[0] == "" {
+		return QueryFilter{}, fmt.Errorf("filter should be of type 'field=value'. Received: %s", filter)
+	}
+	return QueryFilter{Field: split[0], Value: split[1]}, nil
+}
+
+// IsEmpty is true if the query returns all the documents like ListDocuments
+func (q Query) IsEmpty() bool {
+	return len(q.Filters) == 0 && q.Since.IsZero() && q.OrderBy == "" && q.Limit == 0 && q.PageToken == ""
+}
+
+// Values encodes the query as url parameters
+func (q Query) Values() url.Values {
+	values := url.Values{}
+	for _, filter := range q.Filters {
+		values.Add("where", filter.Field+"="+filter.Value)
+	}
+	if !q.Since.IsZero() {
+		values.Set("since_field", q.SinceField)
+		values.Set("since", q.Since.Format(time.RFC3339Nano))
+	}
+	if q.OrderBy != "" {
+		values.Set("order_by", q.OrderBy)
+	}
+	if q.Limit > 0 {
+		values.Set("limit", strconv.Itoa(q.Limit))
+	}
+	if q.PageToken != "" {
+		values.Set("page_token", q.PageToken)
+	}
+	return values
+}
+
+// ParseQuery decodes a query encoded with Values
+func ParseQuery(values url.Values) (Query, error) {
+	q := Query{
+		SinceField: values.Get("since_field"),
+		OrderBy:    values.Get("order_by"),
+		PageToken:  values.Get("page_token"),
+	}
+	for _, where := range values["where"] {
+		filter, err := ParseQueryFilter(where)
+		if err != nil {
+			return Query{}, err
+		}
+		q.Filters = append(q.Filters, filter)
+	}
+	if since := values.Get("since"); since != "" {
+		var err error
+		if q.Since, err = time.Parse(time.RFC3339Nano, since); err != nil {
+			return Query{}, err
+		}
+	}
+	if limit := values.Get("limit"); limit != "" {
+		var err error
+		if q.Limit, err = strconv.Atoi(limit); err != nil {
+			return Query{}, err
+		}
+	}
+	return q, nil
+}
+
+// compareDocumentValues orders the field values of documents decoded from JSON. Timestamps are
+// stored as RFC3339 strings
+func compareDocumentValues(a interface{}, b interface{}) int {
+	if a == nil || b == nil {
+		if a == nil && b == nil {
+			return 0
+		} else if a == nil {
+			return -1
+		}
+		return 1
+	}
+
+	if timeA, ok := documentTime(a); ok {
+		if timeB, ok := documentTime(b); ok {
+			if timeA.Before(timeB) {
+				return -1
+			} else if timeA.After(timeB) {
+				return 1
+			}
+			return 0
+		}
+	}
+
+	if numberA, ok := a.(float64); ok {
+		if numberB, ok := b.(float64); ok {
+			if numberA < numberB {
+				return -1
+			} else if numberA > numberB {
+				return 1
+			}
+			return 0
+		}
+	}
+
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+func documentTime(value interface{}) (time.Time, bool) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, true
+	case string:
+		t, err := time.Parse(time.RFC3339Nano, v)
+		return t, err == nil
+	}
+	return time.Time{}, false
+}
+
+// queryDocuments runs the query on all the documents of a collection, for backends that can't query
+func queryDocuments(docs []map[string]interface{}, q Query) ([]map[string]interface{}, string, error) {
+	var matching []map[string]interface{}
+	for _, doc := range docs {
+		matches := true
+		for _, filter := range q.Filters {
+			if !filter.matches(doc[filter.Field]) {
+				matches = false
+				break
+			}
+		}
+		if matches && !q.Since.IsZero() {
+			t, ok := documentTime(doc[q.SinceField])
+			matches = ok && !t.Before(q.Since)
+		}
+		if matches {
+			matching = append(matching, doc)
+		}
+	}
+
+	sort.SliceStable(matching, func(i, j int) bool {
+		return compareDocumentValues(matching[i]["id"], matching[j]["id"]) < 0
+	})
+	if q.OrderBy != "" {
+		field := strings.TrimPrefix(q.OrderBy, "-")
+		descending := strings.HasPrefix(q.OrderBy, "-")
+		sort.SliceStable(matching, func(i, j int) bool {
+			if descending {
+				return compareDocumentValues(matching[i][field], matching[j][field]) > 0
+			}
+			return compareDocumentValues(matching[i][field], matching[j][field]) < 0
+		})
+	}
+
+	if q.PageToken != "" {
+		start := -1
+		for i, doc := range matching {
+			if doc["id"] == q.PageToken {
+				start = i + 1
+				break
+			}
+		}
+		if start == -1 {
+			return nil, "", fmt.Errorf("invalid page token %s", q.PageToken)
+		}
+		matching = matching[start:]
+	}
+
+	nextPageToken := ""
+	if q.Limit > 0 && len(matching) > q.Limit {
+		matching = matching[:q.Limit]
+		nextPageToken, _ = matching[q.Limit-1]["id"].(string)
+	}
+
+	return matching, nextPageToken, nil
+}
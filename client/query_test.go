@@ -0,0 +1,110 @@
+package client
+
+import (
+	"testing"
+	"time"
+)
+
+func TestQueryDocuments(t *testing.T) {
+	now := time.Now()
+	docs := []map[string]interface{}{
+		{"id": "a", "status": "crash", "branch": "master", "started_at": now.Add(-48 * time.Hour).Format(time.RFC3339Nano)},
+		{"id": "b", "status": "pass", "branch": "master", "started_at": now.Add(-2 * time.Hour).Format(time.RFC3339Nano)},
+		{"id": "c", "status": "crash", "branch": "dev", "started_at": now.Add(-1 * time.Hour).Format(time.RFC3339Nano)},
+		{"id": "d", "status": "crash", "branch": "master", "started_at": now.Add(-3 * time.Hour).Format(time.RFC3339Nano)},
+	}
+
+	ids := func(docs []map[string]interface{}) string {
+		s := ""
+		for _, doc := range docs {
+			s += doc["id"].(string)
+		}
+		return s
+	}
+
+	testCases := []struct {
+		query         Query
+		expected      string
+		nextPageToken string
+	}{
+		{Query{}, "abcd", ""},
+		{Query{Filters: []QueryFilter{{"status", "crash"}, {"branch", "master"}}}, "ad", ""},
+		{Query{SinceField: "started_at", Since: now.Add(-24 * time.Hour)}, "bcd", ""},
+		{Query{OrderBy: "started_at"}, "adbc", ""},
+		{Query{OrderBy: "-started_at", Limit: 2}, "cb", "b"},
+		{Query{OrderBy: "-started_at", Limit: 2, PageToken: "b"}, "da", ""},
+	}
+	for _, tc := range testCases {
+		result, nextPageToken, err := queryDocuments(docs, tc.query)
+		if err != nil {
+			t.Errorf("%+v: %v", tc.query, err)
+			continue
+		}
+		if ids(result) != tc.expected || nextPageToken != tc.nextPageToken {
+			t.Errorf("%+v: expected %s (%q) received %s (%q)", tc.query, tc.expected, tc.nextPageToken, ids(result), nextPageToken)
+		}
+	}
+
+	q := Query{Filters: []QueryFilter{{"status", "crash"}}, SinceField: "time", Since: now, OrderBy: "-time", Limit: 5, PageToken: "x"}
+	parsed, err := ParseQuery(q.Values())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parsed.Filters) != 1 || parsed.Filters[0] != q.Filters[0] || !parsed.Since.Equal(q.Since) ||
+		parsed.SinceField != q.SinceField || parsed.OrderBy != q.OrderBy || parsed.Limit != q.Limit || parsed.PageToken != q.PageToken {
+		t.Errorf("expected %+v received %+v", q, parsed)
+	}
+}
+
+func TestQueryFilterTypedValue(t *testing.T) {
+	testCases := []struct {
+		filter   QueryFilter
+		expected interface{}
+	}{
+		{QueryFilter{"public_corpus", "true"}, true},
+		{QueryFilter{"count", "12"}, int64(12)},
+		{QueryFilter{"exit_code", "crash"}, "crash"},
+		// only the fields stored as bools or numbers are converted
+		{QueryFilter{"revision", "5e3"}, "5e3"},
+		{QueryFilter{"branch", "1"}, "1"},
+		{QueryFilter{"branch", "true"}, "true"},
+	}
+	for _, tc := range testCases {
+		if value := tc.filter.typedValue(); value != tc.expected {
+			t.Errorf("%+v: expected %#v received %#v", tc.filter, tc.expected, value)
+		}
+	}
+
+	// documents decoded from JSON match like typed backends
+	doc := map[string]interface{}{"count": float64(1000000), "v2": true, "revision": "5e3", "branch": "1"}
+	for filter, expected := range map[QueryFilter]bool{
+		{"count", "1000000"}: true,
+		{"count", "1e+06"}:   false,
+		{"v2", "true"}:       true,
+		{"revision", "5e3"}:  true,
+		{"revision", "5000"}: false,
+		{"branch", "1"}:      true,
+		{"missing", "<nil>"}: false,
+	} {
+		if matches := filter.matches(doc[filter.Field]); matches != expected {
+			t.Errorf("%+v: expected %v received %v", filter, expected, matches)
+		}
+	}
+}
+
+func TestGetResourceSinceOrderBy(t *testing.T) {
+	c, _, _, cleanup := newFileClient(t)
+	defer cleanup()
+
+	if err := c.CreateTarget(Target{Name: "parse-complex"}, "", false); err != nil {
+		t.Fatal(err)
+	}
+	since := time.Now().Add(-time.Hour)
+	if err := c.GetResource("targets/parse-complex/jobs", "json", Query{Since: since, OrderBy: "-started_at"}); err != nil {
+		t.Error(err)
+	}
+	// firestore can't order by another field than the one of --since
+	if err := c.GetResource("targets/parse-complex/jobs", "json", Query{Since: since, OrderBy: "status"}); err == nil {
+		t.Error("was expecting an error when ordering by another field than the one of --since")
+	}
+}
@@ -0,0 +1,181 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// HTTPBackend talks to a self-hosted `fuzzit server`. Documents are read and written through the
+// server /v1 api and blobs are transferred via storage links, the same way as the hosted service
+type HTTPBackend struct {
+	signedURLStorage
+	Endpoint   string
+	ApiKey     string
+	idToken    string
+	httpClient *http.Client
+}
+
+func NewHTTPBackend(endpoint string, apiKey string) *HTTPBackend {
+	endpoint = strings.TrimSuffix(endpoint, "/")
+
+	b := &HTTPBackend{}
+	b.Endpoint = endpoint
+	b.ApiKey = apiKey
+	b.httpClient = &http.Client{Timeout: 120 * time.Second}
+	b.signedURLStorage = signedURLStorage{endpoint: endpoint, apiKey: apiKey}
+	return b
+}
+
+func (b *HTTPBackend) Authenticate() (Account, error) {
+	if b.ApiKey == "" {
+		log.Println("FUZZIT_API_KEY is not set. continue with public auth...")
+		return Account{}, nil
+	}
+
+	tokens, err := fetchTokens(b.httpClient, b.Endpoint, b.Endpoint+"/identitytoolkit/v3/relyingparty/verifyCustomToken", b.ApiKey)
+	if err != nil {
+		return Account{}, err
+	}
+	b.idToken = tokens.IdToken
+
+	return Account{Org: tokens.Org, Namespace: tokens.Namespace}, nil
+}
+
+func (b *HTTPBackend) do(method string, path string, body interface{}, dst interface{}) error {
+	var reqBody bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&reqBody).Encode(body); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequest(method, fmt.Sprintf("%s/v1/%s", b.Endpoint, path), &reqBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if b.idToken != "" {
+		req.Header.Set("Authorization", "Bearer "+b.idToken)
+	}
+
+	res, err := b.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return errors.New(res.Status)
+	}
+
+	if dst == nil {
+		return nil
+	}
+	return json.NewDecoder(res.Body).Decode(dst)
+}
+
+func (b *HTTPBackend) GetDocument(path string) (map[string]interface{}, error) {
+	var doc map[string]interface{}
+	if err := b.do("GET", path, nil, &doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+func (b *HTTPBackend) ListDocuments(path string) ([]map[string]interface{}, error) {
+	var docs []map[string]interface{}
+	if err := b.do("GET", path, nil, &docs); err != nil {
+		return nil, err
+	}
+	return docs, nil
+}
+
+func (b *HTTPBackend) QueryDocuments(path string, query Query) ([]map[string]interface{}, string, error) {
+	var page DocumentsPage
+	if err := b.do("GET", path+"?"+query.Values().Encode(), nil, &page); err != nil {
+		return nil, "", err
+	}
+	return page.Documents, page.NextPageToken, nil
+}
+
+func (b *HTTPBackend) DeleteDocument(path string) error {
+	if err := b.do("DELETE", path, nil, nil); err != nil && err != ErrNotFound {
+		return err
+	}
+	return nil
+}
+
+func (b *HTTPBackend) GetTarget(org string, targetId string) (*Target, error) {
+	target := Target{}
+	if err := b.do("GET", fmt.Sprintf("orgs/%s/targets/%s", org, targetId), nil, &target); err != nil {
+		return nil, err
+	}
+	return &target, nil
+}
+
+func (b *HTTPBackend) SetTarget(org string, target Target) error {
+	return b.do("PUT", fmt.Sprintf("orgs/%s/targets/%s", org, target.Name), target, nil)
+}
+
+func (b *HTTPBackend) NewJobId(org string, targetId string) string {
+	return strings.Replace(uuid.New().String(), "-", "", -1)
+}
+
+func (b *HTTPBackend) GetJob(org string, targetId string, jobId string) (*Job, error) {
+	job := Job{}
+	if err := b.do("GET", fmt.Sprintf("orgs/%s/targets/%s/jobs/%s", org, targetId, jobId), nil, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (b *HTTPBackend) SetJob(org string, jobId string, job Job) error {
+	return b.do("PUT", fmt.Sprintf("orgs/%s/targets/%s/jobs/%s", org, job.TargetId, jobId), job, nil)
+}
+
+func (b *HTTPBackend) UpdateJobStatus(org string, targetId string, jobId string, status string) error {
+	return b.do("PATCH",
+		fmt.Sprintf("orgs/%s/targets/%s/jobs/%s", org, targetId, jobId),
+		map[string]interface{}{"status": status},
+		nil)
+}
+
+func (b *HTTPBackend) NewCrashId(org string, targetId string, jobId string) string {
+	return strings.Replace(uuid.New().String(), "-", "", -1)
+}
+
+func (b *HTTPBackend) SetCrash(org string, crashId string, crash Crash) error {
+	return b.do("PUT", fmt.Sprintf("orgs/%s/targets/%s/jobs/%s/crashes/%s", org, crash.TargetId, crash.JobId, crashId), crash, nil)
+}
+
+func (b *HTTPBackend) AddIssueCrash(org string, targetId string, signature string, issue Issue) (*Issue, error) {
+	var stored Issue
+	if err := b.do("POST", fmt.Sprintf("orgs/%s/targets/%s/issues/%s", org, targetId, signature), issue, &stored); err != nil {
+		return nil, err
+	}
+	return &stored, nil
+}
+
+func (b *HTTPBackend) ClearIssueArtifact(org string, targetId string, signature string) error {
+	return b.do("PATCH", fmt.Sprintf("orgs/%s/targets/%s/issues/%s", org, targetId, signature),
+		map[string]string{"artifact": ""}, nil)
+}
+
+func (b *HTTPBackend) AddJobStats(org string, targetId string, jobId string, stats JobStats) error {
+	return b.do("POST", fmt.Sprintf("orgs/%s/targets/%s/jobs/%s/stats", org, targetId, jobId), stats, nil)
+}
+
+func (b *HTTPBackend) SetJobLogs(org string, targetId string, jobId string, logsId string, logs JobLogs) error {
+	return b.do("PUT", fmt.Sprintf("orgs/%s/targets/%s/jobs/%s/logs/%s", org, targetId, jobId, logsId), logs, nil)
+}
@@ -0,0 +1,271 @@
+package client
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// S3Storage keeps blobs in an S3-compatible bucket (AWS S3, MinIO, ...) using the same keys as the
+// hosted storage, e.g orgs/<org>/targets/<target>/corpus.tar.gz. Requests are signed with AWS SigV4
+type S3Storage struct {
+	Endpoint  string // scheme://host[:port]
+	Bucket    string
+	Prefix    string
+	Region    string
+	AccessKey string
+	SecretKey string
+	uri       string
+}
+
+// NewS3Storage parses s3://[access_key:secret_key@]host[:port]/bucket[/prefix][?region=us-east-1&insecure=true].
+// If the credentials are missing from the uri they are read from AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY
+func NewS3Storage(uri string) (*S3Storage, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	path := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if u.Host == "" || path[0] == "" {
+		return nil, fmt.Errorf("s3 storage should be of the form s3://host/bucket. Received: %s", uri)
+	}
+
+	s := &S3Storage{}
+	s.uri = uri
+	s.Bucket = path[0]
+	s.Prefix = strings.Join(path[1:], "/")
+	s.Endpoint = "https://" + u.Host
+	if u.Query().Get("insecure") == "true" {
+		s.Endpoint = "http://" + u.Host
+	}
+	s.Region = u.Query().Get("region")
+	if s.Region == "" {
+		s.Region = GetValueFromEnv("AWS_REGION", "AWS_DEFAULT_REGION")
+	}
+	if s.Region == "" {
+		s.Region = "us-east-1"
+	}
+
+	if u.User != nil {
+		s.AccessKey = u.User.Username()
+		s.SecretKey, _ = u.User.Password()
+	} else {
+		s.AccessKey = os.Getenv("AWS_ACCESS_KEY_ID")
+		s.SecretKey = os.Getenv("AWS_SECRET_ACCESS_KEY")
+	}
+	if s.AccessKey == "" || s.SecretKey == "" {
+		return nil, errors.New("s3 storage requires credentials. set AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY")
+	}
+
+	return s, nil
+}
+
+func (s *S3Storage) objectURL(storagePath string) string {
+	key := storagePath
+	if s.Prefix != "" {
+		key = s.Prefix + "/" + storagePath
+	}
+	return fmt.Sprintf("%s/%s/%s", s.Endpoint, s.Bucket, key)
+}
+
+func (s *S3Storage) UploadFile(filePath string, storagePath string, filename string) error {
+	payloadHash, err := sha256File(filePath)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer data.Close()
+	info, err := data.Stat()
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("PUT", s.objectURL(storagePath), data)
+	if err != nil {
+		return err
+	}
+	// S3 rejects chunked uploads without a Content-Length. net/http treats a zero length with a body as unknown
+	req.ContentLength = info.Size()
+	if info.Size() == 0 {
+		req.Body = http.NoBody
+	}
+	req.Header.Set("Content-Disposition", "attachment; filename="+filename)
+	signV4(req, payloadHash, s.AccessKey, s.SecretKey, s.Region, time.Now())
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		bodyBytes, err := ioutil.ReadAll(res.Body)
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("%s: %s", res.Status, string(bodyBytes))
+	}
+
+	return nil
+}
+
+func (s *S3Storage) DownloadFile(filePath string, storagePath string) (string, error) {
+	req, err := http.NewRequest("GET", s.objectURL(storagePath), nil)
+	if err != nil {
+		return "", err
+	}
+	signV4(req, emptyPayloadHash, s.AccessKey, s.SecretKey, s.Region, time.Now())
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+	if res.StatusCode == http.StatusNotFound {
+		return "", ErrNotFound
+	}
+	if res.StatusCode != http.StatusOK {
+		return "", errors.New(res.Status)
+	}
+
+	out, err := os.Create(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer out.Close()
+
+	if _, err := io.Copy(out, res.Body); err != nil {
+		return "", err
+	}
+
+	filename := ""
+	split := strings.Split(res.Header.Get("Content-Disposition"), "filename=")
+	if len(split) == 2 {
+		filename = split[1]
+	}
+
+	return filename, nil
+}
+
+func (s *S3Storage) DeleteFile(storagePath string) error {
+	req, err := http.NewRequest("DELETE", s.objectURL(storagePath), nil)
+	if err != nil {
+		return err
+	}
+	signV4(req, emptyPayloadHash, s.AccessKey, s.SecretKey, s.Region, time.Now())
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	// S3 answers 204 whether the object existed or not
+	if res.StatusCode != http.StatusNoContent && res.StatusCode != http.StatusOK && res.StatusCode != http.StatusNotFound {
+		return errors.New(res.Status)
+	}
+
+	return nil
+}
+
+// sha256 of an empty payload
+const emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+func sha256File(filePath string) (string, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// s3URIEncode encodes a path the way SigV4 expects it for S3: every byte except unreserved characters and '/'
+func s3URIEncode(path string) string {
+	var encoded strings.Builder
+	for _, b := range []byte(path) {
+		if (b >= 'A' && b <= 'Z') || (b >= 'a' && b <= 'z') || (b >= '0' && b <= '9') ||
+			b == '-' || b == '_' || b == '.' || b == '~' || b == '/' {
+			encoded.WriteByte(b)
+		} else {
+			fmt.Fprintf(&encoded, "%%%02X", b)
+		}
+	}
+	return encoded.String()
+}
+
+// signV4 adds the AWS SigV4 Authorization header to req. The host and all the headers already
+// set on req are signed
+func signV4(req *http.Request, payloadHash string, accessKey string, secretKey string, region string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := now.UTC().Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for k, v := range req.Header {
+		headers[strings.ToLower(k)] = strings.TrimSpace(strings.Join(v, ","))
+	}
+	var headerNames []string
+	for k := range headers {
+		headerNames = append(headerNames, k)
+	}
+	sort.Strings(headerNames)
+
+	var canonicalHeaders strings.Builder
+	for _, k := range headerNames {
+		canonicalHeaders.WriteString(k + ":" + headers[k] + "\n")
+	}
+	signedHeaders := strings.Join(headerNames, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		s3URIEncode(req.URL.Path),
+		strings.Replace(req.URL.Query().Encode(), "+", "%20", -1),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := fmt.Sprintf("%s/%s/s3/aws4_request", date, region)
+	canonicalRequestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hex.EncodeToString(canonicalRequestHash[:]),
+	}, "\n")
+
+	signingKey := hmacSHA256([]byte("AWS4"+secretKey), date)
+	signingKey = hmacSHA256(signingKey, region)
+	signingKey = hmacSHA256(signingKey, "s3")
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		accessKey, scope, signedHeaders, signature))
+}
@@ -0,0 +1,211 @@
+package client
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// crashReportFrames is the number of stack frames kept in a crash report
+const crashReportFrames = 5
+
+// CrashReport is the structured part of a sanitizer report, Go panic or Java exception
+type CrashReport struct {
+	// Sanitizer is AddressSanitizer, MemorySanitizer, UndefinedBehaviorSanitizer, LeakSanitizer, libFuzzer, go or java
+	Sanitizer string
+	// BugType is e.g heap-buffer-overflow, use-after-free or nil pointer dereference
+	BugType    string
+	AccessType string
+	AccessSize uint32
+	// Frames are the top frames of the crashing stack, "function location"
+	Frames []string
+}
+
+var (
+	// ==1234==ERROR: AddressSanitizer: heap-buffer-overflow on address 0x602000000011 at pc ...
+	// ==1234==WARNING: MemorySanitizer: use-of-uninitialized-value
+	sanitizerErrorRegexp = regexp.MustCompile(`==\d+==\s*(?:ERROR|WARNING): (\w+Sanitizer|libFuzzer): (.*)$`)
+	// /src/parse.c:12:5: runtime error: signed integer overflow: 2147483647 + 1 cannot be represented in type 'int'
+	ubsanErrorRegexp = regexp.MustCompile(`^(\S+:\d+:\d+): runtime error: ([^:]+)`)
+	// READ of size 4 at 0x602000000011 thread T0
+	sanitizerAccessRegexp = regexp.MustCompile(`^(READ|WRITE) of size (\d+) at`)
+	// Direct leak of 4 byte(s) in 1 object(s) allocated from:
+	leakSizeRegexp = regexp.MustCompile(`^(Direct|Indirect) leak of (\d+) byte`)
+	// #0 0x4f8e2a in LLVMFuzzerTestOneInput /src/parse.c:12:3
+	sanitizerFrameRegexp = regexp.MustCompile(`^\s*#\d+ 0x[0-9a-f]+ in (\S+)\s*(.*)$`)
+
+	// go test indents the output of failing fuzz tests e.g "    testing.go:1349: panic: runtime error: ..."
+	goPanicRegexp     = regexp.MustCompile(`(?:^|: )panic: (.*)$`)
+	goFatalRegexp     = regexp.MustCompile(`(?:^|: )fatal error: (.*)$`)
+	goLocationRegexp  = regexp.MustCompile(`^\t(\S+:\d+)`)
+	goGoroutineRegexp = regexp.MustCompile(`^goroutine \d+ \[`)
+
+	// Exception in thread "main" java.lang.ArrayIndexOutOfBoundsException: Index 5 out of bounds for length 3
+	// == Java Exception: java.lang.NullPointerException
+	javaExceptionRegexp = regexp.MustCompile(`((?:[a-zA-Z_$][\w$]*\.)+[A-Z][\w$]*(?:Exception|Error|Issue\w*))(?::|$)`)
+	// 	at com.example.Parser.parse(Parser.java:12)
+	javaFrameRegexp = regexp.MustCompile(`^\s+at ([\w$.<>/]+)\((.*)\)$`)
+)
+
+// sanitizerInternalFrames aren't useful to tell crashes apart
+var sanitizerInternalFrames = []string{"__asan_", "__msan_", "__ubsan_", "__sanitizer", "__interceptor_", "__lsan_"}
+
+func isInternalFrame(function string) bool {
+	for _, prefix := range sanitizerInternalFrames {
+		if strings.HasPrefix(function, prefix) {
+			return true
+		}
+	}
+	return strings.HasPrefix(function, "com.code_intelligence.jazzer.") ||
+		strings.HasPrefix(function, "runtime.") || strings.HasPrefix(function, "runtime/debug.") || function == "panic"
+}
+
+func (r *CrashReport) addFrame(function string, location string) {
+	if len(r.Frames) >= crashReportFrames || isInternalFrame(function) {
+		return
+	}
+	r.Frames = append(r.Frames, strings.TrimSpace(function+" "+location))
+}
+
+// ParseCrashReport finds the first sanitizer report, Go panic or Java exception in the output of a
+// fuzzer. The report is empty if none was found
+func ParseCrashReport(lines []string) CrashReport {
+	for i, line := range lines {
+		if match := sanitizerErrorRegexp.FindStringSubmatch(line); match != nil {
+			return parseSanitizerReport(match[1], sanitizerBugType(match[1], match[2]), lines[i+1:])
+		}
+		if match := ubsanErrorRegexp.FindStringSubmatch(line); match != nil {
+			report := parseSanitizerReport("UndefinedBehaviorSanitizer", match[2], lines[i+1:])
+			if len(report.Frames) == 0 {
+				report.Frames = []string{match[1]}
+			}
+			return report
+		}
+		if match := goPanicRegexp.FindStringSubmatch(line); match != nil {
+			return parseGoReport(goBugType(match[1]), lines[i+1:])
+		}
+		if match := goFatalRegexp.FindStringSubmatch(line); match != nil {
+			return parseGoReport(strings.TrimPrefix(match[1], "runtime: "), lines[i+1:])
+		}
+		if strings.Contains(line, "Exception") || strings.Contains(line, "Error") || strings.Contains(line, "FuzzerSecurityIssue") {
+			if match := javaExceptionRegexp.FindStringSubmatch(line); match != nil &&
+				i+1 < len(lines) && javaFrameRegexp.MatchString(lines[i+1]) {
+				return parseJavaReport(match[1], lines[i+1:])
+			}
+		}
+	}
+
+	return CrashReport{}
+}
+
+// sanitizerBugType drops the addresses and values from the description of a sanitizer error
+// e.g "heap-buffer-overflow on address 0x602000000011 at pc 0x4f8e2a" or "timeout after 25 seconds"
+func sanitizerBugType(sanitizer string, description string) string {
+	if sanitizer == "LeakSanitizer" {
+		return "memory-leak"
+	}
+	for _, separator := range []string{" on ", " at ", " (", " after ", ":"} {
+		if i := strings.Index(description, separator); i > 0 {
+			description = description[:i]
+		}
+	}
+	return strings.TrimSpace(description)
+}
+
+func parseSanitizerReport(sanitizer string, bugType string, lines []string) CrashReport {
+	report := CrashReport{Sanitizer: sanitizer, BugType: bugType}
+
+	inStack := false
+	for _, line := range lines {
+		if match := sanitizerAccessRegexp.FindStringSubmatch(line); match != nil && report.AccessType == "" {
+			report.AccessType = match[1]
+			size, _ := strconv.ParseUint(match[2], 10, 32)
+			report.AccessSize = uint32(size)
+			continue
+		}
+		if match := leakSizeRegexp.FindStringSubmatch(line); match != nil && report.AccessSize == 0 {
+			size, _ := strconv.ParseUint(match[2], 10, 32)
+			report.AccessSize = uint32(size)
+			continue
+		}
+		if match := sanitizerFrameRegexp.FindStringSubmatch(line); match != nil {
+			inStack = true
+			report.addFrame(match[1], match[2])
+			continue
+		}
+		// only the first stack is the crashing one
+		if inStack || strings.HasPrefix(line, "SUMMARY:") {
+			break
+		}
+	}
+
+	return report
+}
+
+// goBugType drops the values from a panic message e.g "runtime error: index out of range [5] with length 3"
+func goBugType(message string) string {
+	message = strings.TrimPrefix(message, "runtime error: ")
+	switch {
+	case strings.Contains(message, "nil pointer dereference"):
+		return "nil pointer dereference"
+	case strings.HasPrefix(message, "index out of range"):
+		return "index out of range"
+	case strings.HasPrefix(message, "slice bounds out of range"):
+		return "slice bounds out of range"
+	case strings.HasPrefix(message, "integer divide by zero"):
+		return "integer divide by zero"
+	case strings.HasPrefix(message, "makeslice: "):
+		return "makeslice"
+	}
+	return "panic"
+}
+
+func parseGoReport(bugType string, lines []string) CrashReport {
+	report := CrashReport{Sanitizer: "go", BugType: bugType}
+
+	inStack := false
+	for i, line := range lines {
+		line = strings.TrimLeft(line, " ")
+		if goGoroutineRegexp.MatchString(line) {
+			// only the first goroutine is the crashing one
+			if inStack {
+				break
+			}
+			inStack = true
+			continue
+		}
+		if !inStack || strings.HasPrefix(line, "\t") {
+			continue
+		}
+		if line == "" || strings.HasPrefix(line, "created by ") {
+			break
+		}
+
+		// main.parse({0xc000012345, 0x3, 0x8}) or main.(*Parser).parse(...)
+		function := line
+		if j := strings.LastIndex(line, "("); j > 0 && strings.HasSuffix(line, ")") {
+			function = line[:j]
+		}
+		location := ""
+		if i+1 < len(lines) {
+			if match := goLocationRegexp.FindStringSubmatch(strings.TrimLeft(lines[i+1], " ")); match != nil {
+				location = match[1]
+			}
+		}
+		report.addFrame(function, location)
+	}
+
+	return report
+}
+
+func parseJavaReport(exception string, lines []string) CrashReport {
+	report := CrashReport{Sanitizer: "java", BugType: exception}
+	for _, line := range lines {
+		match := javaFrameRegexp.FindStringSubmatch(line)
+		if match == nil {
+			break
+		}
+		report.addFrame(match[1], match[2])
+	}
+	return report
+}
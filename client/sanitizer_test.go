@@ -0,0 +1,89 @@
+package client
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseCrashReport(t *testing.T) {
+	testCases := []struct {
+		name     string
+		output   string
+		expected CrashReport
+	}{
+		{"asan", `INFO: Seed: 1234
+=================================================================
+==17==ERROR: AddressSanitizer: heap-buffer-overflow on address 0x602000000011 at pc 0x4f8e2a bp 0x7ffc sp 0x7ffc
+READ of size 1 at 0x602000000011 thread T0
+    #0 0x4f8e2a in parse /src/parse.c:12:3
+    #1 0x4f8f10 in LLVMFuzzerTestOneInput /src/fuzz.c:7:5
+    #2 0x42c0a1 in fuzzer::Fuzzer::ExecuteCallback(unsigned char const*, unsigned long) /src/libfuzzer/FuzzerLoop.cpp:556:15
+
+0x602000000011 is located 0 bytes to the right of 1-byte region [0x602000000010,0x602000000011)
+allocated by thread T0 here:
+    #0 0x4c3a2d in __interceptor_malloc
+    #1 0x42c0a1 in fuzzer::Fuzzer::ExecuteCallback(unsigned char const*, unsigned long) /src/libfuzzer/FuzzerLoop.cpp:541:23
+SUMMARY: AddressSanitizer: heap-buffer-overflow /src/parse.c:12:3 in parse`,
+			CrashReport{"AddressSanitizer", "heap-buffer-overflow", "READ", 1, []string{
+				"parse /src/parse.c:12:3",
+				"LLVMFuzzerTestOneInput /src/fuzz.c:7:5",
+				"fuzzer::Fuzzer::ExecuteCallback(unsigned char const*, unsigned long) /src/libfuzzer/FuzzerLoop.cpp:556:15",
+			}}},
+		{"segv", `==17==ERROR: AddressSanitizer: SEGV on unknown address 0x000000000000 (pc 0x4f8e2a bp 0x7ffc sp 0x7ffc T0)
+    #0 0x4f8e2a in parse /src/parse.c:20:9`,
+			CrashReport{"AddressSanitizer", "SEGV", "", 0, []string{"parse /src/parse.c:20:9"}}},
+		{"lsan", `==17==ERROR: LeakSanitizer: detected memory leaks
+
+Direct leak of 32 byte(s) in 1 object(s) allocated from:
+    #0 0x4c3a2d in __interceptor_malloc
+    #1 0x4f8e2a in copy /src/parse.c:30:14`,
+			CrashReport{"LeakSanitizer", "memory-leak", "", 32, []string{"copy /src/parse.c:30:14"}}},
+		{"ubsan", `/src/parse.c:40:12: runtime error: signed integer overflow: 2147483647 + 1 cannot be represented in type 'int'
+SUMMARY: UndefinedBehaviorSanitizer: undefined-behavior /src/parse.c:40:12`,
+			CrashReport{"UndefinedBehaviorSanitizer", "signed integer overflow", "", 0, []string{"/src/parse.c:40:12"}}},
+		{"libfuzzer timeout", `==17== ERROR: libFuzzer: timeout after 25 seconds`,
+			CrashReport{"libFuzzer", "timeout", "", 0, nil}},
+		{"go", `panic: runtime error: index out of range [5] with length 3
+
+goroutine 1 [running]:
+example.com/parser.(*Parser).next(...)
+	/go/src/example.com/parser/parser.go:42
+example.com/parser.Fuzz({0x7f0e3c, 0x3, 0x3})
+	/go/src/example.com/parser/fuzz.go:8 +0x1d
+main.main()
+	/tmp/go-fuzz-main.go:14 +0x3a`,
+			CrashReport{"go", "index out of range", "", 0, []string{
+				"example.com/parser.(*Parser).next /go/src/example.com/parser/parser.go:42",
+				"example.com/parser.Fuzz /go/src/example.com/parser/fuzz.go:8",
+				"main.main /tmp/go-fuzz-main.go:14",
+			}}},
+		{"go test", `--- FAIL: FuzzParse (0.01s)
+    --- FAIL: FuzzParse (0.00s)
+        testing.go:1349: panic: runtime error: invalid memory address or nil pointer dereference
+            goroutine 7 [running]:
+            runtime/debug.Stack()
+            	/usr/local/go/src/runtime/debug/stack.go:24 +0x90
+            example.com/parser.Parse(...)
+            	/go/src/example.com/parser/parser.go:17`,
+			CrashReport{"go", "nil pointer dereference", "", 0, []string{
+				"example.com/parser.Parse /go/src/example.com/parser/parser.go:17",
+			}}},
+		{"java", `== Java Exception: java.lang.ArrayIndexOutOfBoundsException: Index 5 out of bounds for length 3
+	at com.example.Parser.parse(Parser.java:12)
+	at com.example.ParserFuzzer.fuzzerTestOneInput(ParserFuzzer.java:7)
+== libFuzzer crashing input ==`,
+			CrashReport{"java", "java.lang.ArrayIndexOutOfBoundsException", "", 0, []string{
+				"com.example.Parser.parse Parser.java:12",
+				"com.example.ParserFuzzer.fuzzerTestOneInput ParserFuzzer.java:7",
+			}}},
+		{"none", `#2	INITED cov: 3 ft: 3 corp: 1/1b exec/s: 0 rss: 26Mb`, CrashReport{}},
+	}
+
+	for _, tc := range testCases {
+		report := ParseCrashReport(strings.Split(tc.output, "\n"))
+		if !reflect.DeepEqual(report, tc.expected) {
+			t.Errorf("%s: expected %+v received %+v", tc.name, tc.expected, report)
+		}
+	}
+}
@@ -23,10 +23,17 @@ type storageLinkResponse struct {
 	StorageLink string `json:"storage_link"`
 }
 
-func (c *FuzzitClient) getStorageLink(storagePath string, action string) (string, error) {
-	uri := fmt.Sprintf("https://app.fuzzit.dev/getStorageLinkV3?path=%s&api_key=%s&action=%s",
+// signedURLStorage uploads and downloads blobs via the links returned by the getStorageLinkV3 endpoint
+type signedURLStorage struct {
+	endpoint string
+	apiKey   string
+}
+
+func (b *signedURLStorage) getStorageLink(storagePath string, action string) (string, error) {
+	uri := fmt.Sprintf("%s/getStorageLinkV3?path=%s&api_key=%s&action=%s",
+		b.endpoint,
 		url.QueryEscape(storagePath),
-		url.QueryEscape(c.ApiKey),
+		url.QueryEscape(b.apiKey),
 		action)
 	r, err := httpClient.Get(uri)
 	if err != nil {
@@ -46,14 +53,14 @@ func (c *FuzzitClient) getStorageLink(storagePath string, action string) (string
 	return res.StorageLink, nil
 }
 
-func (c *FuzzitClient) uploadFile(filePath string, storagePath string, filename string) error {
+func (b *signedURLStorage) UploadFile(filePath string, storagePath string, filename string) error {
 	data, err := os.Open(filePath)
 	if err != nil {
 		return err
 	}
 	defer data.Close()
 
-	storageLink, err := c.getStorageLink(storagePath, "create")
+	storageLink, err := b.getStorageLink(storagePath, "create")
 	if err != nil {
 		return err
 	}
@@ -99,38 +106,81 @@ func (c *FuzzitClient) archiveAndUpload(dirPath string, storagePath string, file
 	return nil
 }
 
-func (c *FuzzitClient) downloadFile(filePath string, storagePath string) error {
-	storageLink, err := c.getStorageLink(storagePath, "read")
+func (b *signedURLStorage) DownloadFile(filePath string, storagePath string) (string, error) {
+	storageLink, err := b.getStorageLink(storagePath, "read")
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	out, err := os.Create(filePath)
 	if err != nil {
-		return err
+		return "", err
 	}
 	defer out.Close()
 
 	resp, err := http.Get(storageLink)
 	if err != nil {
-		return err
+		return "", err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != 200 {
-		return errors.New(resp.Status)
+		return "", errors.New(resp.Status)
 	}
 
+	filename := ""
 	split := strings.Split(resp.Header.Get("Content-Disposition"), "filename=")
 	if len(split) == 2 {
-		c.fuzzerFilename = split[1]
+		filename = split[1]
 	}
 
 	_, err = io.Copy(out, resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	return filename, nil
+}
+
+func (b *signedURLStorage) DeleteFile(storagePath string) error {
+	storageLink, err := b.getStorageLink(storagePath, "delete")
 	if err != nil {
 		return err
 	}
 
+	req, err := http.NewRequest("DELETE", storageLink, nil)
+	if err != nil {
+		return err
+	}
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return errors.New(res.Status)
+	}
+	return nil
+}
+
+func (c *FuzzitClient) uploadFile(filePath string, storagePath string, filename string) error {
+	return c.backend.UploadFile(filePath, storagePath, filename)
+}
+
+func (c *FuzzitClient) downloadFile(filePath string, storagePath string) error {
+	filename, err := c.backend.DownloadFile(filePath, storagePath)
+	if err != nil {
+		return err
+	}
+
+	if filename != "" {
+		c.fuzzerFilename = filename
+	}
+
 	return nil
 }
 
@@ -145,8 +195,8 @@ func (c *FuzzitClient) downloadAndExtract(dirPath string, storagePath string) er
 		return err
 	}
 
-	if strings.HasSuffix(storagePath, "/fuzzer") && c.currentJob.Engine == "go-fuzz" {
-		if _, err := copyFile(filepath.Join(dirPath, "fuzzer.zip"), tmpArchiveFile.Name()); err != nil {
+	if engine, err := GetEngine(c.currentJob.Engine); err == nil && engine.RawFuzzer() != "" && strings.HasSuffix(storagePath, "/fuzzer") {
+		if _, err := copyFile(filepath.Join(dirPath, engine.RawFuzzer()), tmpArchiveFile.Name()); err != nil {
 			return err
 		}
 
@@ -0,0 +1,28 @@
+// Command gofuzz is a go-fuzz-build cover binary executing the inputs like go-fuzz-dep without the coverage
+package main
+
+import (
+	"encoding/binary"
+	"io"
+	"os"
+)
+
+func main() {
+	comm := os.NewFile(3, "comm")
+	in := os.NewFile(4, "in")
+	out := os.NewFile(5, "out")
+	for {
+		request := make([]byte, 9)
+		if _, err := io.ReadFull(in, request); err != nil {
+			os.Exit(1)
+		}
+		input := make([]byte, binary.LittleEndian.Uint64(request[1:]))
+		if _, err := comm.ReadAt(input, 64<<10); err != nil {
+			panic(err)
+		}
+		if string(input) == "crash" {
+			panic("crash input")
+		}
+		out.Write(make([]byte, 24))
+	}
+}
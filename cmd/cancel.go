@@ -0,0 +1,68 @@
+/*
+Copyright © 2019 fuzzit.dev, inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"log"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// cancelCmd represents the cancel command
+var cancelCmd = &cobra.Command{
+	Use:   "cancel <target_id>/<job_id>",
+	Short: "cancel running jobs",
+	Example: `
+	./fuzzit cancel <target_id>/<job_id> # cancel a job and wait for it to stop
+	./fuzzit cancel <target_id> --all # cancel all the queued and in progress jobs of a target`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		all, err := cmd.Flags().GetBool("all")
+		if err != nil {
+			log.Fatal(err)
+		}
+		timeout, err := cmd.Flags().GetDuration("timeout")
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		jobSplice := strings.Split(args[0], "/")
+		var jobIds []string
+		if all {
+			if len(jobSplice) != 1 {
+				log.Fatalf("--all cancels all the jobs of a target. Received: %s", args[0])
+			}
+		} else {
+			if len(jobSplice) != 2 || jobSplice[0] == "" || jobSplice[1] == "" {
+				log.Fatalf("[JOB] should be of type 'target/job'. Received: %s", args[0])
+			}
+			jobIds = []string{jobSplice[1]}
+		}
+
+		err = gFuzzitClient.CancelJobs(jobSplice[0], jobIds, timeout)
+		if err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(cancelCmd)
+	cancelCmd.Flags().Bool("all", false, "cancel all the queued and in progress jobs of the target")
+	cancelCmd.Flags().Duration("timeout", 5*time.Minute, "how long to wait for the agents to stop the jobs")
+}
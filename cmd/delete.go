@@ -0,0 +1,96 @@
+/*
+Copyright © 2019 fuzzit.dev, inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"log"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// deleteCmd represents the delete command
+var deleteCmd = &cobra.Command{
+	Use:   "delete",
+	Short: "Delete a Target, a Job or a Crash along with their storage",
+}
+
+// splitResource splits a target/job/crash argument into its n ids
+func splitResource(arg string, n int, usage string) []string {
+	split := strings.Split(arg, "/")
+	for _, id := range split {
+		if len(split) != n || id == "" {
+			log.Fatalf("should be of type '%s'. Received: %s", usage, arg)
+		}
+	}
+	return split
+}
+
+var deleteTargetCmd = &cobra.Command{
+	Use:   "target <target_id>",
+	Short: "delete a target with its jobs, crashes, issues, corpus and seed",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		dryRun, err := cmd.Flags().GetBool("dry-run")
+		if err != nil {
+			log.Fatal(err)
+		}
+		target := splitResource(args[0], 1, "target")
+		if err := gFuzzitClient.DeleteTarget(target[0], dryRun); err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+var deleteJobCmd = &cobra.Command{
+	Use:   "job <target_id>/<job_id>",
+	Short: "delete a job with its crashes, fuzzer and additional corpus",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		dryRun, err := cmd.Flags().GetBool("dry-run")
+		if err != nil {
+			log.Fatal(err)
+		}
+		job := splitResource(args[0], 2, "target/job")
+		if err := gFuzzitClient.DeleteJob(job[0], job[1], dryRun); err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+var deleteCrashCmd = &cobra.Command{
+	Use:   "crash <target_id>/<job_id>/<crash_id>",
+	Short: "delete a crash and its artifact",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		dryRun, err := cmd.Flags().GetBool("dry-run")
+		if err != nil {
+			log.Fatal(err)
+		}
+		crash := splitResource(args[0], 3, "target/job/crash")
+		if err := gFuzzitClient.DeleteCrash(crash[0], crash[1], crash[2], dryRun); err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(deleteCmd)
+	deleteCmd.PersistentFlags().Bool("dry-run", false, "list the documents and storage objects that would be deleted")
+	deleteCmd.AddCommand(deleteTargetCmd)
+	deleteCmd.AddCommand(deleteJobCmd)
+	deleteCmd.AddCommand(deleteCrashCmd)
+}
@@ -0,0 +1,94 @@
+/*
+Copyright © 2019 fuzzit.dev, inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"log"
+
+	"github.com/spf13/cobra"
+)
+
+// downloadCmd represents the download command
+var downloadCmd = &cobra.Command{
+	Use:   "download",
+	Short: "Download the corpus, seed, fuzzer or a crash",
+}
+
+// downloadDir returns the --output directory of the download commands
+func downloadDir(cmd *cobra.Command) string {
+	dst, err := cmd.Flags().GetString("output")
+	if err != nil {
+		log.Fatal(err)
+	}
+	return dst
+}
+
+var downloadCorpusCmd = &cobra.Command{
+	Use:   "corpus <target_id>",
+	Short: "download and extract the corpus of a target",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		target := splitResource(args[0], 1, "target")
+		if err := gFuzzitClient.DownloadCorpus(downloadDir(cmd), target[0]); err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+var downloadSeedCmd = &cobra.Command{
+	Use:   "seed <target_id>",
+	Short: "download and extract the seed corpus of a target",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		target := splitResource(args[0], 1, "target")
+		if err := gFuzzitClient.DownloadSeed(downloadDir(cmd), target[0]); err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+var downloadFuzzerCmd = &cobra.Command{
+	Use:   "fuzzer <target_id>/<job_id>",
+	Short: "download and extract the fuzzer of a job",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		job := splitResource(args[0], 2, "target/job")
+		if err := gFuzzitClient.DownloadFuzzer(downloadDir(cmd), job[0], job[1]); err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+var downloadCrashCmd = &cobra.Command{
+	Use:   "crash <target_id>/<job_id>/<crash_id>",
+	Short: "download the input of a crash",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		crash := splitResource(args[0], 3, "target/job/crash")
+		if _, err := gFuzzitClient.DownloadCrash(downloadDir(cmd), crash[0], crash[1], crash[2]); err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(downloadCmd)
+	downloadCmd.PersistentFlags().StringP("output", "o", ".", "directory to download to")
+	downloadCmd.AddCommand(downloadCorpusCmd)
+	downloadCmd.AddCommand(downloadSeedCmd)
+	downloadCmd.AddCommand(downloadFuzzerCmd)
+	downloadCmd.AddCommand(downloadCrashCmd)
+}
@@ -17,7 +17,9 @@ package cmd
 
 import (
 	"log"
+	"time"
 
+	"github.com/fuzzitdev/fuzzit/v2/client"
 	"github.com/spf13/cobra"
 )
 
@@ -29,10 +31,56 @@ var getCmd = &cobra.Command{
 	./fuzzit get targets # retrieve all targets
 	./fuzzit get targets/<target_id> # retrieve specific target
 	./fuzzit get targets/<target_id>/jobs # retrieve all jobs for target
-	./fuzzit get targets/<target_id>/jobs/<job_id> # retrieve specific job`,
+	./fuzzit get targets/<target_id>/jobs/<job_id> # retrieve specific job
+	./fuzzit get targets/<target_id>/jobs -o table # print the jobs as a table
+	./fuzzit get targets/<target_id>/jobs -o jsonpath='{[*].status}' # print the status of all jobs
+	./fuzzit get targets/<target_id>/jobs --where status=crash --since 24h --order-by -started_at --limit 10`,
 	Args: cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		err := gFuzzitClient.GetResource(args[0])
+		output, err := cmd.Flags().GetString("output")
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		query := client.Query{}
+		where, err := cmd.Flags().GetStringArray("where")
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, w := range where {
+			filter, err := client.ParseQueryFilter(w)
+			if err != nil {
+				log.Fatal(err)
+			}
+			query.Filters = append(query.Filters, filter)
+		}
+		for _, field := range []string{"branch", "revision"} {
+			value, err := cmd.Flags().GetString(field)
+			if err != nil {
+				log.Fatal(err)
+			}
+			if value != "" {
+				query.Filters = append(query.Filters, client.QueryFilter{Field: field, Value: value})
+			}
+		}
+		since, err := cmd.Flags().GetDuration("since")
+		if err != nil {
+			log.Fatal(err)
+		}
+		if since > 0 {
+			query.Since = time.Now().Add(-since)
+		}
+		if query.OrderBy, err = cmd.Flags().GetString("order-by"); err != nil {
+			log.Fatal(err)
+		}
+		if query.Limit, err = cmd.Flags().GetInt("limit"); err != nil {
+			log.Fatal(err)
+		}
+		if query.PageToken, err = cmd.Flags().GetString("page-token"); err != nil {
+			log.Fatal(err)
+		}
+
+		err = gFuzzitClient.GetResource(args[0], output, query)
 		if err != nil {
 			log.Fatal(err)
 		}
@@ -41,4 +89,12 @@ var getCmd = &cobra.Command{
 
 func init() {
 	rootCmd.AddCommand(getCmd)
+	getCmd.Flags().StringP("output", "o", "json", "output format. One of: json|yaml|table|jsonpath=<expr>|go-template=<template>")
+	getCmd.Flags().StringArray("where", nil, "only get the documents with field=value e.g status=crash (can be repeated)")
+	getCmd.Flags().String("branch", "", "only get the jobs of a branch")
+	getCmd.Flags().String("revision", "", "only get the jobs of a revision")
+	getCmd.Flags().Duration("since", 0, "only get the documents created in the last duration e.g 24h")
+	getCmd.Flags().String("order-by", "", "field to order by e.g started_at. prefix with - to order descending. with --since it has to be the field of --since")
+	getCmd.Flags().Int("limit", 0, "maximum number of documents to get. the next page token is printed on stderr")
+	getCmd.Flags().String("page-token", "", "get the page following the one that printed this token")
 }
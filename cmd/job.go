@@ -17,6 +17,7 @@ package cmd
 
 import (
 	"log"
+	"os"
 	"strconv"
 	"strings"
 	"time"
@@ -47,18 +48,26 @@ var allowedCPUs = map[string]bool{
 var jobCmd = &cobra.Command{
 	Use:   "job [target_id] [files...]",
 	Short: "create new fuzzing job",
-	Args:  cobra.MinimumNArgs(2),
+	Args: func(cmd *cobra.Command, args []string) error {
+		// the fuzzer is located by --cargo-fuzz
+		if cargoFuzz, _ := cmd.Flags().GetString("cargo-fuzz"); cargoFuzz != "" {
+			return cobra.MinimumNArgs(1)(cmd, args)
+		}
+		return cobra.MinimumNArgs(2)(cmd, args)
+	},
 	Run: func(cmd *cobra.Command, args []string) {
 		if newJob.Type != "fuzzing" && newJob.Type != "regression" && newJob.Type != "local-regression" {
 			log.Fatalf("--type should be either fuzzing, local-regression or regression(DEPERCATED). Received: %s", newJob.Type)
 		}
 
-		if newJob.Engine != "libfuzzer" && newJob.Engine != "jqf" && newJob.Engine != "go-fuzz" {
-			log.Fatalf("--engine should be one of libfuzzer/go-fuzz/jqf. Received: %s", newJob.Type)
+		engine, err := client.GetEngine(newJob.Engine)
+		if err != nil {
+			log.Fatalf("--%s", err)
 		}
 
-		if !allowedCPUs[newJob.CPUs] {
-			log.Fatalf("got %s cpus. CPUs can only be one of 0.1,0.2,0.3,0.4,0.5,0.6,0.7,0.8,0.9,1,1.0\n", newJob.CPUs)
+		// whole numbers of cpus run a libfuzzer worker per cpu
+		if cpus, err := strconv.Atoi(newJob.CPUs); !allowedCPUs[newJob.CPUs] && (err != nil || cpus < 1) {
+			log.Fatalf("got %s cpus. CPUs can only be one of 0.1,0.2,0.3,0.4,0.5,0.6,0.7,0.8,0.9,1,1.0 or a whole number of cpus\n", newJob.CPUs)
 		}
 
 		if !strings.HasSuffix(newJob.Memory, "Mi") {
@@ -73,14 +82,57 @@ var jobCmd = &cobra.Command{
 			log.Fatalf("got %d Mi memory. > 2048Mi memory is only supported for enterprise customers\n", i)
 		}
 
+		additionalCorpus, err := cmd.Flags().GetString("additional-corpus")
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		// log.Fatal skips deferred calls so the corpus archived for --cargo-fuzz is removed before exiting
+		archivedCorpus := ""
+		fatal := func(v ...interface{}) {
+			if archivedCorpus != "" {
+				os.Remove(archivedCorpus)
+			}
+			log.Fatal(v...)
+		}
+
+		files := args[1:]
+		cargoFuzz, err := cmd.Flags().GetString("cargo-fuzz")
+		if err != nil {
+			log.Fatal(err)
+		}
+		if cargoFuzz != "" {
+			if newJob.Engine != "libfuzzer" {
+				log.Fatalf("--cargo-fuzz fuzz targets use the libfuzzer engine. Received: %s", newJob.Engine)
+			}
+			binary, triple, corpus, err := client.CargoFuzzTarget(cargoFuzz)
+			if err != nil {
+				log.Fatal(err)
+			}
+			log.Printf("Found fuzz target %s", binary)
+			files = append([]string{binary}, files...)
+
+			if additionalCorpus == "" && corpus != "" {
+				additionalCorpus, err = client.ArchiveCorpus(corpus)
+				if err != nil {
+					log.Fatal(err)
+				}
+				archivedCorpus = additionalCorpus
+				defer os.Remove(archivedCorpus)
+			}
+
+			if newJob.Host == "" {
+				newJob.Host, err = client.CargoFuzzHost(triple)
+				if err != nil {
+					fatal(err)
+				}
+			}
+		}
+
 		image := client.HostToDocker[newJob.Host]
 		if image == "" {
 			if newJob.Host == "" {
-				if newJob.Engine == "jqf" {
-					image = "openjdk:stretch"
-				} else {
-					image = "gcr.io/fuzzit-public/stretch-llvm8:64bdedf"
-				}
+				image = engine.DefaultHost()
 			} else {
 				image = newJob.Host
 			}
@@ -89,12 +141,7 @@ var jobCmd = &cobra.Command{
 
 		skipIfNotExist, err := cmd.Flags().GetBool("skip-if-not-exists")
 		if err != nil {
-			log.Fatal(err)
-		}
-
-		additionalCorpus, err := cmd.Flags().GetString("additional-corpus")
-		if err != nil {
-			log.Fatal(err)
+			fatal(err)
 		}
 
 		log.Println("Creating job...")
@@ -102,7 +149,7 @@ var jobCmd = &cobra.Command{
 		target := args[0]
 		targetSplice := strings.Split(args[0], "/")
 		if len(targetSplice) > 2 {
-			log.Fatalf("[TARGET] can only be of type 'target' or 'project/target-name'.")
+			fatal("[TARGET] can only be of type 'target' or 'project/target-name'.")
 		} else if len(targetSplice) == 2 {
 			target = targetSplice[1]
 			gFuzzitClient.Org = targetSplice[0]
@@ -112,7 +159,7 @@ var jobCmd = &cobra.Command{
 
 		if newJob.Type == "local-regression" {
 			start := time.Now()
-			err = gFuzzitClient.CreateLocalJob(newJob, args[1:])
+			err = gFuzzitClient.CreateLocalJob(newJob, additionalCorpus, files)
 			if err != nil && skipIfNotExist && (err.Error() == "401 Unauthorized" || err.Error() == "fuzzer exited with 22") {
 				log.Println("Target doesn't exist yet. skipping...")
 				return
@@ -120,12 +167,12 @@ var jobCmd = &cobra.Command{
 			diff := time.Now().Sub(start)
 			log.Printf("Regression for %s took %s seconds", target, diff)
 		} else {
-			_, err = gFuzzitClient.CreateJob(newJob, additionalCorpus, args[1:])
+			_, err = gFuzzitClient.CreateJob(newJob, additionalCorpus, files)
 			log.Printf("Job created successfully")
 		}
 
 		if err != nil {
-			log.Fatal(err)
+			fatal(err)
 		}
 
 	},
@@ -148,7 +195,7 @@ func init() {
 	branch := client.GetValueFromEnv("TRAVIS_BRANCH", "CIRCLE_BRANCH", "GITHUB_REF")
 
 	jobCmd.Flags().StringVar(&newJob.Type, "type", "fuzzing", "fuzzing/local-regression")
-	jobCmd.Flags().StringVar(&newJob.Engine, "engine", "libfuzzer", "libfuzzer/jqf/go-fuzz")
+	jobCmd.Flags().StringVar(&newJob.Engine, "engine", "libfuzzer", strings.Join(client.EngineNames(), "/"))
 	jobCmd.Flags().StringVar(&newJob.CPUs, "cpus", "1", "number of cpus to use (only relevant for fuzzing job)")
 	jobCmd.Flags().StringVar(&newJob.Memory, "memory", "2048Mi", "number of cpus to use (only relevant for fuzzing job)")
 	jobCmd.Flags().MarkHidden("memory")
@@ -156,9 +203,11 @@ func init() {
 	jobCmd.Flags().StringVar(&newJob.Revision, "revision", revision, "revision tag of fuzzer (populates automatically from git,travis,circleci)")
 	jobCmd.Flags().StringVar(&newJob.Branch, "branch", branch, "branch of the fuzzer (populates automatically from git,travis,circleci)")
 	jobCmd.Flags().String("additional-corpus", "", "path to additional corpus for this job (should be a flat zip/tar.gz containing the test cases)")
-	jobCmd.Flags().StringVar(&newJob.Host, "host", "", "docker image to use when running the fuzzer. Options: stretch-llvm8/stretch-llvm9/bionic-swift51")
+	jobCmd.Flags().StringVar(&newJob.Host, "host", "", "docker image to use when running the fuzzer. Options: stretch-llvm8/stretch-llvm9/bionic-swift51/stretch-python3/bionic-python3")
 	jobCmd.Flags().StringArrayVarP(&newJob.EnvironmentVariables, "environment", "e", nil,
 		"Additional environment variables for the fuzzer. For example ASAN_OPTINOS, UBSAN_OPTIONS or any other")
-	jobCmd.Flags().StringVar(&newJob.Args, "args", "", "Additional runtime args for the fuzzer")
+	jobCmd.Flags().StringVar(&newJob.Args, "args", "", "Additional runtime args for the fuzzer. honggfuzz fuzzers built for persistent mode take --persistent")
+	jobCmd.Flags().IntVar(&newJob.MinimizeRuns, "minimize-runs", 0, "minimize crashing inputs with this many runs before uploading them next to the original (libfuzzer, jqf, jazzer and atheris, go-fuzz crashers are always minimized)")
+	jobCmd.Flags().String("cargo-fuzz", "", "cargo fuzz target to use as the fuzzer. its corpus in fuzz/corpus/<target> is used as the additional corpus")
 	jobCmd.Flags().Bool("skip-if-not-exists", false, "skip/don't fail if target doesnt exists yet. useful for automatic target creation")
 }
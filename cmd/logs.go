@@ -0,0 +1,54 @@
+/*
+Copyright © 2019 fuzzit.dev, inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"log"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// logsCmd represents the logs command
+var logsCmd = &cobra.Command{
+	Use:   "logs <target_id>/<job_id>",
+	Short: "print the logs of a job",
+	Example: `
+	./fuzzit logs <target_id>/<job_id> # print the logs shipped so far
+	./fuzzit logs -f <target_id>/<job_id> # keep printing the logs until the job finishes`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		follow, err := cmd.Flags().GetBool("follow")
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		jobSplice := strings.Split(args[0], "/")
+		if len(jobSplice) != 2 || jobSplice[0] == "" || jobSplice[1] == "" {
+			log.Fatalf("[JOB] should be of type 'target/job'. Received: %s", args[0])
+		}
+
+		err = gFuzzitClient.PrintJobLogs(jobSplice[0], jobSplice[1], follow)
+		if err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(logsCmd)
+	logsCmd.Flags().BoolP("follow", "f", false, "follow the logs until the job finishes")
+}
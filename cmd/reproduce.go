@@ -0,0 +1,43 @@
+/*
+Copyright © 2019 fuzzit.dev, inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"log"
+
+	"github.com/spf13/cobra"
+)
+
+// reproduceCmd represents the reproduce command
+var reproduceCmd = &cobra.Command{
+	Use:   "reproduce <target_id>/<job_id>/<crash_id>",
+	Short: "replay a crash locally in the docker image of its job",
+	Long: `Runs the fuzzer of the job on the crash in the docker image of the job, with the environment
+variables and args of the job, and prints the report of the fuzzer. Requires docker. On linux/amd64
+this fuzzit is copied to the container to run the fuzzer, other platforms use the released agent of
+this version`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		crash := splitResource(args[0], 3, "target/job/crash")
+		if err := gFuzzitClient.Reproduce(crash[0], crash[1], crash[2]); err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(reproduceCmd)
+}
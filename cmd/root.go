@@ -34,14 +34,45 @@ var rootCmd = &cobra.Command{
 	Version: client.Version,
 	PersistentPreRun: func(cmd *cobra.Command, args []string) {
 		apiKey := viper.GetString("api-key")
-		var err error
-		gFuzzitClient, err = client.NewFuzzitClient(apiKey)
+		// the hosted firestore only trusts app.fuzzit.dev so a self-hosted server serves the documents too
+		if endpoint := viper.GetString("endpoint"); endpoint != "" {
+			if backend := viper.GetString("backend"); backend != "" && backend != endpoint {
+				log.Fatalf("--endpoint sends every call to the server and can't be used with --backend %s", backend)
+			}
+			viper.Set("backend", endpoint)
+		}
+		backend, err := newBackend(apiKey)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		gFuzzitClient, err = client.NewFuzzitClientWithBackend(apiKey, backend)
 		if err != nil {
 			log.Fatalln(err)
 		}
+		if org := viper.GetString("org"); org != "" {
+			gFuzzitClient.Org = org
+		}
 	},
 }
 
+// newBackend creates the backend selected by --backend and --storage
+func newBackend(apiKey string) (client.Backend, error) {
+	backend, err := client.NewBackend(viper.GetString("backend"), apiKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if storageURI := viper.GetString("storage"); storageURI != "" {
+		storage, err := client.NewStorage(storageURI)
+		if err != nil {
+			return nil, err
+		}
+		backend = client.WithStorage(backend, storage)
+	}
+
+	return backend, nil
+}
+
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Println(err)
@@ -55,6 +86,22 @@ func init() {
 	if err := viper.BindPFlag("api-key", rootCmd.PersistentFlags().Lookup("api-key")); err != nil {
 		log.Fatalln(err)
 	}
+	rootCmd.PersistentFlags().String("org", "", "Organization id when it can't be inferred from the api key, e.g with a file backend (can also be passed via env: FUZZIT_ORG)")
+	if err := viper.BindPFlag("org", rootCmd.PersistentFlags().Lookup("org")); err != nil {
+		log.Fatalln(err)
+	}
+	rootCmd.PersistentFlags().String("endpoint", "", "Self-hosted fuzzit server to use for authentication, documents and storage, same as --backend <endpoint> (can also be passed via env: FUZZIT_ENDPOINT)")
+	if err := viper.BindPFlag("endpoint", rootCmd.PersistentFlags().Lookup("endpoint")); err != nil {
+		log.Fatalln(err)
+	}
+	rootCmd.PersistentFlags().String("backend", "", "Backend to store targets, jobs and corpora. e.g file:///var/lib/fuzzit or http://fuzzit-server:8080 (can also be passed via env: FUZZIT_BACKEND)")
+	if err := viper.BindPFlag("backend", rootCmd.PersistentFlags().Lookup("backend")); err != nil {
+		log.Fatalln(err)
+	}
+	rootCmd.PersistentFlags().String("storage", "", "Storage for corpora, seeds, fuzzers and crashes if it differs from the backend. e.g s3://minio:9000/fuzzit?insecure=true (can also be passed via env: FUZZIT_STORAGE)")
+	if err := viper.BindPFlag("storage", rootCmd.PersistentFlags().Lookup("storage")); err != nil {
+		log.Fatalln(err)
+	}
 }
 
 // initConfig reads in config file and ENV variables if set.
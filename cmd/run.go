@@ -21,6 +21,7 @@ import (
 	"github.com/spf13/cobra"
 	"log"
 	"os"
+	"strings"
 )
 
 var runJob = client.Job{}
@@ -44,7 +45,18 @@ var runCmd = &cobra.Command{
 			jobId = args[2]
 		}
 
-		err = gFuzzitClient.RunFuzzer(runJob, jobId, updateDB)
+		crashId, err := cmd.Flags().GetString("crash")
+		if err != nil {
+			log.Fatal(err)
+		}
+		if crashId != "" {
+			if jobId == "" {
+				log.Fatal("--crash requires the JOB_ID of the crash")
+			}
+			err = gFuzzitClient.RunReproduce(runJob, jobId, crashId)
+		} else {
+			err = gFuzzitClient.RunFuzzer(runJob, jobId, updateDB)
+		}
 		if err != nil {
 			log.Println(err)
 			if err.Error() == "401 Unauthorized" {
@@ -61,6 +73,9 @@ func init() {
 	rootCmd.AddCommand(runCmd)
 	runCmd.Flags().Bool("update-db", false, "if this runs on fuzzit then update db")
 	runCmd.Flags().StringVar(&runJob.Type, "type", "fuzzing", "fuzzing/regression")
-	runCmd.Flags().StringVar(&runJob.Engine, "engine", "libfuzzer", "libfuzzer/jqf/go-fuzz")
+	runCmd.Flags().StringVar(&runJob.Engine, "engine", "libfuzzer", strings.Join(client.EngineNames(), "/"))
 	runCmd.Flags().StringVar(&runJob.Args, "args", "", "Additional runtime args for the fuzzer")
+	runCmd.Flags().String("crash", "", "replay this crash of the job instead of running it")
+	runCmd.Flags().IntVar(&runJob.MinimizeRuns, "minimize-runs", 0, "runs spent minimizing crashing inputs (defaults to the minimize runs of the job)")
+	runCmd.Flags().StringVar(&runJob.CPUs, "cpus", "", "number of cpus to use. libfuzzer runs a worker per cpu (defaults to the cpus of the job)")
 }
@@ -0,0 +1,94 @@
+/*
+Copyright © 2019 fuzzit.dev, inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/fuzzitdev/fuzzit/v2/server"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// serverCmd represents the server command
+var serverCmd = &cobra.Command{
+	Use:   "server",
+	Short: "Run a self-hosted fuzzit server",
+	Long: `Run a self-hosted fuzzit server compatible with the cli and the agent.
+Point the cli to it with --backend http://<host>:<port>`,
+	Example: `
+	./fuzzit server --backend file:///var/lib/fuzzit --key <api_key>=<org>
+	./fuzzit server --backend file:///var/lib/fuzzit --storage s3://minio:9000/fuzzit?insecure=true --key <api_key>=<org>`,
+	Args: cobra.NoArgs,
+	// the server doesn't need a fuzzit client
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {},
+	Run: func(cmd *cobra.Command, args []string) {
+		listen, err := cmd.Flags().GetString("listen")
+		if err != nil {
+			log.Fatal(err)
+		}
+		publicURL, err := cmd.Flags().GetString("public-url")
+		if err != nil {
+			log.Fatal(err)
+		}
+		keys, err := cmd.Flags().GetStringArray("key")
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		apiKeys := map[string]string{}
+		for _, key := range keys {
+			split := strings.SplitN(key, "=", 2)
+			if len(split) != 2 || split[0] == "" || split[1] == "" {
+				log.Fatalf("--key should be of the form <api_key>=<org>. Received: %s", key)
+			}
+			apiKeys[split[0]] = split[1]
+		}
+		if len(apiKeys) == 0 {
+			log.Println("no --key was provided. the server will accept any api key and allow all requests, clients have to pass --org")
+		}
+
+		if viper.GetString("backend") == "" {
+			log.Fatal("--backend is required. e.g file:///var/lib/fuzzit")
+		}
+		backend, err := newBackend("")
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		s, err := server.NewServer(backend, apiKeys, []byte(viper.GetString("server-secret")), publicURL)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		log.Printf("Listening on %s", listen)
+		log.Fatal(http.ListenAndServe(listen, s))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(serverCmd)
+
+	serverCmd.Flags().String("listen", ":8080", "address to listen on")
+	serverCmd.Flags().String("public-url", "", "url the server is reachable at, used in storage links (defaults to the request host)")
+	serverCmd.Flags().StringArray("key", nil, "api key accepted by the server and its org in the form <api_key>=<org>. can be repeated")
+	serverCmd.Flags().String("secret", "", "secret used to sign tokens and storage links (can also be passed via env: FUZZIT_SERVER_SECRET)")
+	if err := viper.BindPFlag("server-secret", serverCmd.Flags().Lookup("secret")); err != nil {
+		log.Fatalln(err)
+	}
+}
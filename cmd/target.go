@@ -53,7 +53,7 @@ var targetCmd = &cobra.Command{
 			gFuzzitClient.Org = targetSplice[0]
 		}
 
-		_, err = gFuzzitClient.CreateTarget(newTarget, seed, skipIfExists)
+		err = gFuzzitClient.CreateTarget(newTarget, seed, skipIfExists)
 		if err != nil {
 			log.Fatal(err)
 		}
@@ -0,0 +1,121 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	customTokenKind = "custom"
+	idTokenKind     = "id"
+
+	customTokenTTL = 5 * time.Minute
+	idTokenTTL     = time.Hour
+)
+
+type verifyCustomTokenRequest struct {
+	Token             string `json:"token"`
+	ReturnSecureToken bool   `json:"returnSecureToken"`
+}
+
+func (s *Server) mac(payload string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// newToken returns a token of kind for org that expires after ttl
+func (s *Server) newToken(kind string, org string, ttl time.Duration) string {
+	payload := fmt.Sprintf("%s:%d:%s", kind, time.Now().Add(ttl).Unix(), org)
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." + s.mac(payload)
+}
+
+// verifyToken returns the org of a valid, not expired, token of kind
+func (s *Server) verifyToken(kind string, token string) (string, error) {
+	split := strings.Split(token, ".")
+	if len(split) != 2 {
+		return "", errors.New("malformed token")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(split[0])
+	if err != nil {
+		return "", err
+	}
+	if !hmac.Equal([]byte(s.mac(string(payload))), []byte(split[1])) {
+		return "", errors.New("invalid token signature")
+	}
+
+	fields := strings.SplitN(string(payload), ":", 3)
+	if len(fields) != 3 || fields[0] != kind {
+		return "", errors.New("invalid token")
+	}
+	expires, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return "", err
+	}
+	if time.Now().Unix() > expires {
+		return "", errors.New("token expired")
+	}
+
+	return fields[2], nil
+}
+
+// requestOrg returns the org of the bearer id token of r, or an empty string for anonymous requests
+func (s *Server) requestOrg(r *http.Request) (string, error) {
+	authorization := r.Header.Get("Authorization")
+	if authorization == "" {
+		return "", nil
+	}
+	if !strings.HasPrefix(authorization, "Bearer ") {
+		return "", errors.New("invalid authorization header")
+	}
+	return s.verifyToken(idTokenKind, strings.TrimPrefix(authorization, "Bearer "))
+}
+
+func (s *Server) handleCreateCustomToken(w http.ResponseWriter, r *http.Request) {
+	// without api keys any key is accepted. the clients pass their org with --org
+	org, ok := s.apiKeys[r.URL.Query().Get("api_key")]
+	if !ok && len(s.apiKeys) > 0 {
+		http.Error(w, "invalid api key", http.StatusUnauthorized)
+		return
+	}
+
+	writeJSON(w, map[string]string{
+		"customToken": s.newToken(customTokenKind, org, customTokenTTL),
+		"org":         org,
+	})
+}
+
+func (s *Server) handleVerifyCustomToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req verifyCustomTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	org, err := s.verifyToken(customTokenKind, req.Token)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	writeJSON(w, map[string]string{
+		"kind":         "identitytoolkit#VerifyCustomTokenResponse",
+		"idToken":      s.newToken(idTokenKind, org, idTokenTTL),
+		"refreshToken": "",
+		"expiresIn":    strconv.Itoa(int(idTokenTTL.Seconds())),
+	})
+}
@@ -0,0 +1,161 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/fuzzitdev/fuzzit/v2/client"
+)
+
+// handleDocuments serves the /v1/<path> document api used by client.HTTPBackend. Paths follow the
+// orgs/<org>/targets/<target>/jobs/<job>/crashes/<crash> layout; GET on a collection lists its documents
+func (s *Server) handleDocuments(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/v1/")
+	if !validPath(path) {
+		http.Error(w, "invalid resource", http.StatusBadRequest)
+		return
+	}
+
+	org, err := s.requestOrg(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	if !s.authorized(org, path, false) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	segments := strings.Split(path, "/")
+	isDocument := len(segments)%2 == 0
+
+	switch {
+	case r.Method == "GET" && isDocument:
+		doc, err := s.backend.GetDocument(path)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, doc)
+	case r.Method == "GET" && len(r.URL.Query()) > 0:
+		query, err := client.ParseQuery(r.URL.Query())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		docs, nextPageToken, err := s.backend.QueryDocuments(path, query)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		if docs == nil {
+			docs = []map[string]interface{}{}
+		}
+		writeJSON(w, client.DocumentsPage{Documents: docs, NextPageToken: nextPageToken})
+	case r.Method == "GET":
+		docs, err := s.backend.ListDocuments(path)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		if docs == nil {
+			docs = []map[string]interface{}{}
+		}
+		writeJSON(w, docs)
+	case r.Method == "DELETE" && isDocument:
+		if err := s.backend.DeleteDocument(path); err != nil {
+			writeError(w, err)
+		}
+	case r.Method == "PUT" && len(segments) == 4 && segments[2] == "targets":
+		var target client.Target
+		if !decodeBody(w, r, &target) {
+			return
+		}
+		target.Name = segments[3]
+		if err := s.backend.SetTarget(segments[1], target); err != nil {
+			writeError(w, err)
+		}
+	case r.Method == "PUT" && len(segments) == 6 && segments[4] == "jobs":
+		var job client.Job
+		if !decodeBody(w, r, &job) {
+			return
+		}
+		job.TargetId = segments[3]
+		if err := s.backend.SetJob(segments[1], segments[5], job); err != nil {
+			writeError(w, err)
+		}
+	case r.Method == "PATCH" && len(segments) == 6 && segments[4] == "jobs":
+		var fields map[string]string
+		if !decodeBody(w, r, &fields) {
+			return
+		}
+		status, ok := fields["status"]
+		if !ok || len(fields) != 1 {
+			http.Error(w, "only the status of a job can be updated", http.StatusBadRequest)
+			return
+		}
+		if err := s.backend.UpdateJobStatus(segments[1], segments[3], segments[5], status); err != nil {
+			writeError(w, err)
+		}
+	case r.Method == "PUT" && len(segments) == 8 && segments[6] == "crashes":
+		var crash client.Crash
+		if !decodeBody(w, r, &crash) {
+			return
+		}
+		crash.TargetId = segments[3]
+		crash.JobId = segments[5]
+		if err := s.backend.SetCrash(segments[1], segments[7], crash); err != nil {
+			writeError(w, err)
+		}
+	case r.Method == "POST" && len(segments) == 6 && segments[4] == "issues":
+		var issue client.Issue
+		if !decodeBody(w, r, &issue) {
+			return
+		}
+		stored, err := s.backend.AddIssueCrash(segments[1], segments[3], segments[5], issue)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, stored)
+	case r.Method == "PATCH" && len(segments) == 6 && segments[4] == "issues":
+		var fields map[string]string
+		if !decodeBody(w, r, &fields) {
+			return
+		}
+		if artifact, ok := fields["artifact"]; !ok || artifact != "" || len(fields) != 1 {
+			http.Error(w, "only the artifact of an issue can be cleared", http.StatusBadRequest)
+			return
+		}
+		if err := s.backend.ClearIssueArtifact(segments[1], segments[3], segments[5]); err != nil {
+			writeError(w, err)
+		}
+	case r.Method == "POST" && len(segments) == 7 && segments[6] == "stats":
+		var stats client.JobStats
+		if !decodeBody(w, r, &stats) {
+			return
+		}
+		if err := s.backend.AddJobStats(segments[1], segments[3], segments[5], stats); err != nil {
+			writeError(w, err)
+		}
+	case r.Method == "PUT" && len(segments) == 8 && segments[6] == "logs":
+		var logs client.JobLogs
+		if !decodeBody(w, r, &logs) {
+			return
+		}
+		if err := s.backend.SetJobLogs(segments[1], segments[3], segments[5], segments[7], logs); err != nil {
+			writeError(w, err)
+		}
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func decodeBody(w http.ResponseWriter, r *http.Request, dst interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(dst); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return false
+	}
+	return true
+}
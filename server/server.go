@@ -0,0 +1,123 @@
+package server
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/fuzzitdev/fuzzit/v2/client"
+)
+
+// Server is a self-hosted stand-in for app.fuzzit.dev. It serves the createCustomToken, getStorageLinkV3
+// and identity toolkit endpoints used by the cli and the agent, plus a /v1 document api for client.HTTPBackend.
+// Everything is stored in backend.
+type Server struct {
+	backend   client.Backend
+	apiKeys   map[string]string // api key -> org
+	secret    []byte
+	publicURL string
+	mux       *http.ServeMux
+}
+
+// NewServer creates a server storing everything in backend. apiKeys maps each accepted api key to its org.
+// If apiKeys is empty any api key is accepted, without an org, and every request is allowed, which is only
+// meant for local testing.
+// publicURL is used to build storage links, if empty it is taken from the request Host header.
+func NewServer(backend client.Backend, apiKeys map[string]string, secret []byte, publicURL string) (*Server, error) {
+	if len(secret) == 0 {
+		log.Println("no secret was provided. generating a random one, tokens will be invalidated on restart...")
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, err
+		}
+	}
+
+	s := &Server{
+		backend:   backend,
+		apiKeys:   apiKeys,
+		secret:    secret,
+		publicURL: strings.TrimSuffix(publicURL, "/"),
+		mux:       http.NewServeMux(),
+	}
+
+	s.mux.HandleFunc("/createCustomToken", s.handleCreateCustomToken)
+	s.mux.HandleFunc("/identitytoolkit/v3/relyingparty/verifyCustomToken", s.handleVerifyCustomToken)
+	s.mux.HandleFunc("/getStorageLinkV3", s.handleGetStorageLink)
+	s.mux.HandleFunc("/storage/", s.handleStorage)
+	s.mux.HandleFunc("/v1/", s.handleDocuments)
+
+	return s, nil
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Printf("%s %s", r.Method, r.URL.Path)
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) baseURL(r *http.Request) string {
+	if s.publicURL != "" {
+		return s.publicURL
+	}
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
+
+// authorized checks if org can access path. Anonymous reads of the corpus and the seed are allowed
+// for targets with a public corpus, like in the hosted service.
+func (s *Server) authorized(org string, path string, read bool) bool {
+	if len(s.apiKeys) == 0 {
+		return true
+	}
+
+	segments := strings.Split(path, "/")
+	if len(segments) < 2 || segments[0] != "orgs" {
+		return false
+	}
+	if org != "" && segments[1] == org {
+		return true
+	}
+
+	if read && len(segments) == 5 && segments[2] == "targets" && (segments[4] == "corpus.tar.gz" || segments[4] == "seed") {
+		target, err := s.backend.GetTarget(segments[1], segments[3])
+		if err == nil && target.PublicCorpus {
+			return true
+		}
+	}
+
+	return false
+}
+
+// validPath rejects paths that could escape the storage root of the backend
+func validPath(path string) bool {
+	segments := strings.Split(path, "/")
+	if len(segments) < 2 || segments[0] != "orgs" {
+		return false
+	}
+	for _, segment := range segments {
+		if segment == "" || segment == "." || segment == ".." || strings.Contains(segment, "\\") {
+			return false
+		}
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Println(err)
+	}
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	if err == client.ErrNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	log.Println(err)
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+}
@@ -0,0 +1,199 @@
+package server
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fuzzitdev/fuzzit/v2/client"
+)
+
+func TestServer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fuzzit-server")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	storage, err := client.NewFileBackend(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewServer(storage, map[string]string{"key-a": "org-a", "key-b": "org-b"}, []byte("secret"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	newClient := func(apiKey string) *client.FuzzitClient {
+		c, err := client.NewFuzzitClientWithBackend(apiKey, client.NewHTTPBackend(ts.URL, apiKey))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+
+	clientA := newClient("key-a")
+	if clientA.Org != "org-a" {
+		t.Fatalf("was expecting org-a received %s", clientA.Org)
+	}
+	if _, err := client.NewFuzzitClientWithBackend("invalid", client.NewHTTPBackend(ts.URL, "invalid")); err == nil {
+		t.Errorf("was expecting an error for an invalid api key")
+	}
+
+	if err := clientA.CreateTarget(client.Target{Name: "parse-complex"}, "../client/testdata/fuzzer.tar.gz", false); err != nil {
+		t.Fatal(err)
+	}
+	if err := clientA.CreateTarget(client.Target{Name: "public", PublicCorpus: true}, "../client/testdata/fuzzer.tar.gz", false); err != nil {
+		t.Fatal(err)
+	}
+	jobId, err := clientA.CreateJob(client.Job{TargetId: "parse-complex", Type: "fuzzing", Engine: "libfuzzer"}, "", []string{"../client/testdata/fuzzer"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "orgs/org-a/targets/parse-complex/jobs", jobId, "fuzzer")); err != nil {
+		t.Errorf("fuzzer wasn't stored: %v", err)
+	}
+	if err := clientA.GetResource("targets/parse-complex/jobs/"+jobId, "json", client.Query{}); err != nil {
+		t.Error(err)
+	}
+
+	clientB := newClient("key-b")
+	clientB.Org = "org-a"
+	anonymous := newClient("")
+	anonymous.Org = "org-a"
+
+	testCases := []struct {
+		client *client.FuzzitClient
+		target string
+		err    string
+	}{
+		{clientA, "parse-complex", ""},
+		{clientA, "public", ""},
+		{clientA, "empty-corpus", "404 Not Found"},
+		{clientB, "parse-complex", "401 Unauthorized"},
+		{anonymous, "parse-complex", "401 Unauthorized"},
+		{anonymous, "public", ""},
+	}
+	for _, tc := range testCases {
+		extractDir, err := ioutil.TempDir("", "fuzzit-seed")
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = tc.client.DownloadAndExtractSeed(extractDir, tc.target)
+		os.RemoveAll(extractDir)
+		if err != nil {
+			if err.Error() != tc.err {
+				t.Errorf("target %s: was expecting %s received %s", tc.target, tc.err, err.Error())
+			}
+		} else if tc.err != "" {
+			t.Errorf("target %s: was expecting %s received none", tc.target, tc.err)
+		}
+	}
+
+	backendB := client.NewHTTPBackend(ts.URL, "key-b")
+	if _, err := backendB.Authenticate(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := backendB.GetDocument("orgs/org-a/targets/parse-complex"); err == nil || err.Error() != "401 Unauthorized" {
+		t.Errorf("was expecting 401 Unauthorized received %v", err)
+	}
+	if _, err := backendB.GetDocument("orgs/org-b/targets/../../org-a/targets/parse-complex"); err == nil {
+		t.Errorf("was expecting an error for a path outside of the org")
+	}
+
+	backendA := client.NewHTTPBackend(ts.URL, "key-a")
+	if _, err := backendA.Authenticate(); err != nil {
+		t.Fatal(err)
+	}
+	docs, nextPageToken, err := backendA.QueryDocuments("orgs/org-a/targets/parse-complex/jobs",
+		client.Query{Filters: []client.QueryFilter{{Field: "status", Value: "queued"}}, Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) != 1 || docs[0]["id"] != jobId || nextPageToken != "" {
+		t.Errorf("unexpected query result %v %q", docs, nextPageToken)
+	}
+
+	for i := 1; i <= 2; i++ {
+		issue, err := backendA.AddIssueCrash("org-a", "parse-complex", "signature",
+			client.Issue{BugType: "heap-buffer-overflow", CrashId: "crash1", LastCrashId: fmt.Sprintf("crash%d", i)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if issue.Count != int64(i) || issue.CrashId != "crash1" {
+			t.Errorf("unexpected issue %+v", issue)
+		}
+	}
+	if err := backendA.ClearIssueArtifact("org-a", "parse-complex", "signature"); err != nil {
+		t.Fatal(err)
+	}
+	if issue, err := backendA.GetDocument("orgs/org-a/targets/parse-complex/issues/signature"); err != nil || issue["crash_id"] != "" {
+		t.Errorf("was expecting the issue to forget its input received %v %v", issue, err)
+	}
+
+	if err := backendB.DeleteDocument("orgs/org-a/targets/parse-complex"); err == nil || err.Error() != "401 Unauthorized" {
+		t.Errorf("was expecting 401 Unauthorized received %v", err)
+	}
+
+	if err := clientA.CancelJobs("parse-complex", []string{jobId}, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := clientA.DeleteJob("parse-complex", jobId, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "orgs/org-a/targets/parse-complex/jobs", jobId, "fuzzer")); !os.IsNotExist(err) {
+		t.Errorf("fuzzer wasn't deleted: %v", err)
+	}
+	if err := clientA.GetResource("targets/parse-complex/jobs/"+jobId, "json", client.Query{}); err == nil {
+		t.Errorf("job wasn't deleted")
+	}
+}
+
+func TestServerWithoutKeys(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fuzzit-server")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	storage, err := client.NewFileBackend(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewServer(storage, nil, []byte("secret"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	// any api key is accepted and the org comes from the client
+	c, err := client.NewFuzzitClientWithBackend("any-key", client.NewHTTPBackend(ts.URL, "any-key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Org = "org-a"
+	if err := c.CreateTarget(client.Target{Name: "parse-complex"}, "../client/testdata/fuzzer.tar.gz", false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "orgs/org-a/targets/parse-complex/seed")); err != nil {
+		t.Errorf("seed wasn't stored: %v", err)
+	}
+
+	// the storage links escape the path
+	backend := client.NewHTTPBackend(ts.URL, "any-key")
+	if _, err := backend.Authenticate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := backend.UploadFile("../client/testdata/fuzzer", "orgs/org-a/targets/parse-complex/crash?#1 %", "crash"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "orgs/org-a/targets/parse-complex/crash?#1 %")); err != nil {
+		t.Errorf("crash wasn't stored: %v", err)
+	}
+}
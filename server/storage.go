@@ -0,0 +1,125 @@
+package server
+
+import (
+	"crypto/hmac"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const storageLinkTTL = 15 * time.Minute
+
+func (s *Server) storageSignature(path string, action string, expires string) string {
+	return s.mac(fmt.Sprintf("%s:%s:%s", path, action, expires))
+}
+
+func (s *Server) handleGetStorageLink(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	path := query.Get("path")
+	action := query.Get("action")
+	if !validPath(path) || (action != "read" && action != "create" && action != "delete") {
+		http.Error(w, "invalid path or action", http.StatusBadRequest)
+		return
+	}
+
+	org := ""
+	if apiKey := query.Get("api_key"); apiKey != "" {
+		var ok bool
+		org, ok = s.apiKeys[apiKey]
+		if !ok && len(s.apiKeys) > 0 {
+			http.Error(w, "invalid api key", http.StatusUnauthorized)
+			return
+		}
+	}
+	if !s.authorized(org, path, action == "read") {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	expires := strconv.FormatInt(time.Now().Add(storageLinkTTL).Unix(), 10)
+	link := fmt.Sprintf("%s/storage/%s?action=%s&expires=%s&signature=%s",
+		s.baseURL(r),
+		strings.Join(segments, "/"),
+		action,
+		expires,
+		s.storageSignature(path, action, expires))
+
+	writeJSON(w, map[string]string{"storage_link": link})
+}
+
+func (s *Server) handleStorage(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/storage/")
+	query := r.URL.Query()
+	action := query.Get("action")
+	expires := query.Get("expires")
+
+	if (r.Method == "GET" && action != "read") || (r.Method == "PUT" && action != "create") ||
+		(r.Method == "DELETE" && action != "delete") {
+		http.Error(w, "method doesn't match the storage link", http.StatusMethodNotAllowed)
+		return
+	}
+	if !validPath(path) || !hmac.Equal([]byte(query.Get("signature")), []byte(s.storageSignature(path, action, expires))) {
+		http.Error(w, "invalid signature", http.StatusForbidden)
+		return
+	}
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > expiresAt {
+		http.Error(w, "storage link expired", http.StatusForbidden)
+		return
+	}
+
+	if r.Method == "DELETE" {
+		if err := s.backend.DeleteFile(path); err != nil {
+			writeError(w, err)
+		}
+		return
+	}
+
+	tmpFile, err := ioutil.TempFile("", "fuzzit-server")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	defer os.Remove(tmpFile.Name())
+	defer tmpFile.Close()
+
+	if r.Method == "PUT" {
+		if _, err := io.Copy(tmpFile, r.Body); err != nil {
+			writeError(w, err)
+			return
+		}
+		filename := ""
+		split := strings.Split(r.Header.Get("Content-Disposition"), "filename=")
+		if len(split) == 2 {
+			filename = split[1]
+		}
+		if err := s.backend.UploadFile(tmpFile.Name(), path, filename); err != nil {
+			writeError(w, err)
+			return
+		}
+		return
+	}
+
+	filename, err := s.backend.DownloadFile(tmpFile.Name(), path)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if filename != "" {
+		w.Header().Set("Content-Disposition", "attachment; filename="+filename)
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	if _, err := io.Copy(w, tmpFile); err != nil {
+		writeError(w, err)
+	}
+}